@@ -113,7 +113,7 @@ func TestDgo_validate_bad_port(t *testing.T) {
 	assert.Equal(t, 1, dgo.Do([]string{`--verbose`, `validate`, `--input`, `testdata/service_bad_port.yaml`, `--spec`, `testdata/servicespec.yaml`}))
 	s := out.String()
 	assert.NoMatch(t, `'host' FAILED\!`, s)
-	assert.Match(t, `'port' FAILED\!(?:.|\s)*2222`, s)
+	assert.Match(t, `'port' FAILED\!\n  Reason: testdata/service_bad_port\.yaml:2:7: expected a value of type 1\.\.999, got 2222\n`, s)
 }
 
 func TestDgo_validate_bad_port_brief(t *testing.T) {
@@ -121,7 +121,48 @@ func TestDgo_validate_bad_port_brief(t *testing.T) {
 	err := &strings.Builder{}
 	dgo := cli.Dgo(out, err)
 	assert.Equal(t, 1, dgo.Do([]string{`validate`, `--input`, `testdata/service_bad_port.yaml`, `--spec`, `testdata/servicespec.yaml`}))
-	assert.Equal(t, "parameter 'port' is not an instance of type 1..999\n", out.String())
+	assert.Equal(t, "testdata/service_bad_port.yaml:2:7: parameter 'port' is not an instance of type 1..999\n", out.String())
+}
+
+func TestDgo_validate_extraneous_param_brief(t *testing.T) {
+	out := &strings.Builder{}
+	err := &strings.Builder{}
+	dgo := cli.Dgo(out, err)
+	assert.Equal(t, 1, dgo.Do([]string{`validate`, `--input`, `testdata/service_extraneous_param.yaml`, `--spec`, `testdata/servicespec.yaml`}))
+	assert.Equal(t, "testdata/service_extraneous_param.yaml:3:1: unknown parameter 'login'\n", out.String())
+}
+
+func TestDgo_validate_missing_host_brief(t *testing.T) {
+	out := &strings.Builder{}
+	err := &strings.Builder{}
+	dgo := cli.Dgo(out, err)
+	assert.Equal(t, 1, dgo.Do([]string{`validate`, `--input`, `testdata/service_missing_host.yaml`, `--spec`, `testdata/servicespec.yaml`}))
+	assert.Equal(t, "testdata/service_missing_host.yaml:1:1: missing required parameter 'host'\n", out.String())
+}
+
+func TestDgo_validate_optional_absent(t *testing.T) {
+	out := &strings.Builder{}
+	err := &strings.Builder{}
+	dgo := cli.Dgo(out, err)
+	assert.Equal(t, 0, dgo.Do([]string{`--verbose`, `validate`, `--input`, `testdata/service_no_port.yaml`, `--spec`, `testdata/servicespec.dgo`}))
+	assert.Equal(t, `Got input yaml with:
+  host: example.com
+Validating 'host' against definition string[1]
+  'host' OK!
+Validating 'port' against definition 1..999
+  'port' OK! (not present)
+`, out.String())
+}
+
+func TestDgo_validate_many_errors_brief(t *testing.T) {
+	out := &strings.Builder{}
+	err := &strings.Builder{}
+	dgo := cli.Dgo(out, err)
+	assert.Equal(t, 1, dgo.Do([]string{`validate`, `--input`, `testdata/service_many_errors.yaml`, `--spec`, `testdata/servicespec.yaml`}))
+	assert.Equal(t, `testdata/service_many_errors.yaml:1:1: missing required parameter 'host'
+testdata/service_many_errors.yaml:1:7: parameter 'port' is not an instance of type 1..999
+testdata/service_many_errors.yaml:2:1: unknown parameter 'login'
+`, out.String())
 }
 
 func TestDgo_validate_bad_port_dgo(t *testing.T) {
@@ -142,7 +183,7 @@ func TestDgo_validate_extraneous_param(t *testing.T) {
 	s := out.String()
 	assert.Match(t, `'host' OK\!`, s)
 	assert.Match(t, `'port' OK\!`, s)
-	assert.Match(t, `'login' FAILED\!(?:.|\s)*key is not found in definition`, s)
+	assert.Match(t, `'login' FAILED\!\n  Reason: testdata/service_extraneous_param\.yaml:3:1: key is not found in definition\n`, s)
 }
 
 func TestDgo_validate_missing_host(t *testing.T) {
@@ -152,7 +193,7 @@ func TestDgo_validate_missing_host(t *testing.T) {
 	assert.Equal(t, 1, dgo.Do([]string{`--verbose`, `validate`, `--input`, `testdata/service_missing_host.yaml`, `--spec`, `testdata/servicespec.yaml`}))
 	s := out.String()
 	assert.Match(t, `'port' OK\!`, s)
-	assert.Match(t, `'host' FAILED\!(?:.|\s)*required key not found in input`, s)
+	assert.Match(t, `'host' FAILED\!\n  Reason: testdata/service_missing_host\.yaml:1:1: required key not found in input\n`, s)
 }
 
 func TestDgo_validate_no_input_file(t *testing.T) {
@@ -244,3 +285,57 @@ func TestDgo_validate_bad_yaml(t *testing.T) {
 	s := err.String()
 	assert.Match(t, `did not find expected key`, s)
 }
+
+func TestDgo_validate_dotted_key_brief(t *testing.T) {
+	out := &strings.Builder{}
+	err := &strings.Builder{}
+	dgo := cli.Dgo(out, err)
+	assert.Equal(t, 1, dgo.Do([]string{`validate`, `--input`, `testdata/service_dotted_key.yaml`, `--spec`, `testdata/servicespec.yaml`}))
+	assert.Equal(t, "testdata/service_dotted_key.yaml:3:1: unknown parameter 'port.x'\n", out.String())
+}
+
+func TestDgo_validate_int_key_brief(t *testing.T) {
+	out := &strings.Builder{}
+	err := &strings.Builder{}
+	dgo := cli.Dgo(out, err)
+	assert.Equal(t, 1, dgo.Do([]string{`validate`, `--input`, `testdata/service_int_key.yaml`, `--spec`, `testdata/servicespec.yaml`}))
+	assert.Equal(t, "testdata/service_int_key.yaml:3:1: unknown parameter '8080'\n", out.String())
+}
+
+func TestDgo_validate_limits(t *testing.T) {
+	out := &strings.Builder{}
+	err := &strings.Builder{}
+	dgo := cli.Dgo(out, err)
+	assert.Equal(t, 1, dgo.Do([]string{`validate`, `--max-alias-expansion`, `1000`, `--input`, `testdata/service_alias_bomb.yaml`, `--spec`, `testdata/servicespec.yaml`}))
+	assert.Match(t, `Error: testdata/service_alias_bomb\.yaml:6:8: alias expansion exceeds the maximum of 1000`, err.String())
+
+	err.Reset()
+	assert.Equal(t, 1, dgo.Do([]string{`validate`, `--max-depth`, `1`, `--input`, `testdata/service_alias_bomb.yaml`, `--spec`, `testdata/servicespec.yaml`}))
+	assert.Match(t, `Error: testdata/service_alias_bomb\.yaml:3:4: depth exceeds the maximum of 1`, err.String())
+
+	err.Reset()
+	assert.Equal(t, 1, dgo.Do([]string{`validate`, `--max-nodes`, `4`, `--input`, `testdata/service.yaml`, `--spec`, `testdata/servicespec.yaml`}))
+	assert.Match(t, `Error: testdata/service\.yaml:2:7: node count exceeds the maximum of 4`, err.String())
+
+	err.Reset()
+	assert.Equal(t, 1, dgo.Do([]string{`validate`, `--max-scalar-length`, `4`, `--input`, `testdata/service.yaml`, `--spec`, `testdata/servicespec.yaml`}))
+	assert.Match(t, `Error: testdata/service\.yaml:1:7: scalar length exceeds the maximum of 4`, err.String())
+
+	err.Reset()
+	assert.Equal(t, 1, dgo.Do([]string{`validate`, `--max-input-size`, `26`, `--input`, `testdata/service.yaml`, `--spec`, `testdata/servicespec.yaml`}))
+	assert.Match(t, `Error: testdata/service\.yaml: input size exceeds the maximum of 26`, err.String())
+
+	out.Reset()
+	assert.Equal(t, 0, dgo.Do([]string{`validate`, `--max-input-size`, `27`, `--max-depth`, `1`, `--max-nodes`, `5`, `--max-scalar-length`, `11`, `--input`, `testdata/service.yaml`, `--spec`, `testdata/servicespec.yaml`}))
+}
+
+func TestDgo_validate_json(t *testing.T) {
+	out := &strings.Builder{}
+	err := &strings.Builder{}
+	dgo := cli.Dgo(out, err)
+	assert.Equal(t, 0, dgo.Do([]string{`validate`, `--input`, `testdata/service.json`, `--spec`, `testdata/servicespec.yaml`}))
+	assert.Equal(t, ``, out.String())
+
+	assert.Equal(t, 1, dgo.Do([]string{`validate`, `--input`, `testdata/service_tag.json`, `--spec`, `testdata/servicespec.yaml`}))
+	assert.Match(t, `Error: testdata/service_tag\.json:2:11: host: tags are not allowed in JSON`, err.String())
+}
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"flag"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"strings"
@@ -21,14 +22,23 @@ func Validate(parent Command) Command {
 	flags := flag.NewFlagSet(`validate`, flag.ContinueOnError)
 	flags.StringVar(&vc.input, `input`, ``, `yaml file containing input to validate`)
 	flags.StringVar(&vc.spec, `spec`, ``, `yaml or dgo file with the parameter definitions`)
+	flags.IntVar(&vc.options.MaxInputSize, `max-input-size`, 0,
+		`maximum size in bytes of the input, checked before it is parsed, 0 means no limit`)
+	flags.IntVar(&vc.options.MaxDepth, `max-depth`, 0, `maximum nesting depth of the input, 0 means no limit`)
+	flags.IntVar(&vc.options.MaxNodes, `max-nodes`, 0, `maximum number of nodes in the input, 0 means no limit`)
+	flags.IntVar(&vc.options.MaxScalarLength, `max-scalar-length`, 0,
+		`maximum length of a scalar in the input, 0 means no limit`)
+	flags.IntVar(&vc.options.MaxAliasExpansion, `max-alias-expansion`, 0,
+		`maximum number of nodes that the aliases of the input expand to, 0 means no limit`)
 	vc.flags = flags
 	return vc
 }
 
 type validateCommand struct {
 	command
-	input string
-	spec  string
+	input   string
+	spec    string
+	options yaml.DecodeOptions
 }
 
 func readFileOrPanic(name string) []byte {
@@ -44,19 +54,25 @@ func readFileOrPanic(name string) []byte {
 }
 
 func (h *validateCommand) run() int {
-	iMap := h.loadParameters(h.input)
+	iMap, ps := h.loadParameters(h.input)
 	sType := h.loadStructMapType(h.spec)
+	checks := checkParameters(iMap, sType)
 	ok := true
+	for _, c := range checks {
+		if c.result != checkOK && c.result != checkAbsent {
+			ok = false
+		}
+	}
 	if h.verbose {
 		bld := util.NewIndenter(`  `)
-		ok = sType.(dgo.MapValidation).ValidateVerbose(iMap, bld)
+		writeVerbose(checks, ps, bld)
 		pio.WriteString(h.out, bld.String())
 	} else {
-		vs := sType.(dgo.MapValidation).Validate(nil, iMap)
-		if len(vs) > 0 {
-			ok = false
-			for _, err := range vs {
-				pio.WriteString(h.out, err.Error())
+		for _, c := range checks {
+			if msg := c.message(); msg != `` {
+				pio.WriteString(h.out, c.position(ps).String())
+				pio.WriteString(h.out, `: `)
+				pio.WriteString(h.out, msg)
 				pio.WriteRune(h.out, '\n')
 			}
 		}
@@ -67,11 +83,145 @@ func (h *validateCommand) run() int {
 	return 1
 }
 
-func (h *validateCommand) loadParameters(input string) (iMap dgo.Map) {
+// checkResult is the outcome of a check
+type checkResult int
+
+const (
+	// checkOK means that the value is an instance of the type of its parameter
+	checkOK = checkResult(iota)
+
+	// checkAbsent means that an optional parameter is absent from the input
+	checkAbsent
+
+	// checkMismatch means that the value isn't an instance of the type of its parameter
+	checkMismatch
+
+	// checkMissing means that a required parameter is absent from the input
+	checkMissing
+
+	// checkUnknown means that the input contains a key that isn't a parameter
+	checkUnknown
+)
+
+// check is the result of checking one key of the input against the parameter definitions
+type check struct {
+	key    dgo.Value
+	typ    dgo.Type
+	value  dgo.Value
+	result checkResult
+}
+
+// checkParameters checks the given input against the given parameter definitions. One check is returned for
+// each defined parameter, in the order of the definitions, followed by one check for each key of the input that
+// isn't a parameter.
+func checkParameters(iMap dgo.Map, sType dgo.StructMapType) []*check {
+	var checks []*check
+	sType.EachEntryType(func(e dgo.StructMapEntry) {
+		c := &check{key: e.Key().(dgo.ExactType).ExactValue(), typ: e.Value().(dgo.Type)}
+		c.value = iMap.Get(c.key)
+		switch {
+		case c.value == nil && e.Required():
+			c.result = checkMissing
+		case c.value == nil:
+			c.result = checkAbsent
+		case !c.typ.Instance(c.value):
+			c.result = checkMismatch
+		}
+		checks = append(checks, c)
+	})
+	iMap.EachKey(func(k dgo.Value) {
+		if sType.GetEntryType(k) == nil {
+			checks = append(checks, &check{key: k, result: checkUnknown})
+		}
+	})
+	return checks
+}
+
+// message returns the message for a failed check, or the empty string when the check didn't fail
+func (c *check) message() string {
+	switch c.result {
+	case checkMismatch:
+		return fmt.Sprintf(`parameter '%s' is not an instance of type %s`, keyName(c.key), c.typ)
+	case checkMissing:
+		return `missing required parameter '` + keyName(c.key) + `'`
+	case checkUnknown:
+		return `unknown parameter '` + keyName(c.key) + `'`
+	}
+	return ``
+}
+
+// reason returns the reason for a failed check that is given in verbose output
+func (c *check) reason() string {
+	switch c.result {
+	case checkMismatch:
+		return fmt.Sprintf(`expected a value of type %s, got %#v`, c.typ, c.value)
+	case checkMissing:
+		return `required key not found in input`
+	}
+	return `key is not found in definition`
+}
+
+// position returns the position of the offending part of the input for a failed check. The position of the key
+// is returned for unknown keys, the position of the input itself is returned for missing parameters, and the
+// position of the value is returned otherwise.
+func (c *check) position(ps *yaml.Positions) yaml.Position {
+	var pos yaml.Position
+	switch c.result {
+	case checkUnknown:
+		pos, _ = ps.Key(yaml.Path(c.key))
+	case checkMissing:
+		pos, _ = ps.Value(``)
+	default:
+		pos, _ = ps.Value(yaml.Path(c.key))
+	}
+	return pos
+}
+
+// writeVerbose writes a report of the given checks to the given indenter. The reason for each failed check is
+// prefixed with the position of the offending part of the input.
+func writeVerbose(checks []*check, ps *yaml.Positions, out dgo.Indenter) {
+	inner := out.Indent()
+	for _, c := range checks {
+		if c.result == checkUnknown {
+			out.Printf(`Validating '%s'`, c.key)
+		} else {
+			out.Printf(`Validating '%s' against definition %s`, c.key, c.typ)
+		}
+		inner.NewLine()
+		inner.Printf(`'%s' `, c.key)
+		switch c.result {
+		case checkOK:
+			inner.Append(`OK!`)
+		case checkAbsent:
+			inner.Append(`OK! (not present)`)
+		default:
+			inner.Append(`FAILED!`)
+			inner.NewLine()
+			inner.Printf(`Reason: %s: %s`, c.position(ps), c.reason())
+		}
+		out.NewLine()
+	}
+}
+
+// keyName returns the name of the given key for use in messages
+func keyName(key dgo.Value) string {
+	if s, ok := key.(dgo.String); ok {
+		return s.GoString()
+	}
+	return key.String()
+}
+
+func (h *validateCommand) loadParameters(input string) (iMap dgo.Map, ps *yaml.Positions) {
 	switch {
 	case strings.HasSuffix(input, `.yaml`), strings.HasSuffix(input, `.json`):
 		data := readFileOrPanic(input)
-		m, err := yaml.Unmarshal(data)
+		opts := h.options
+
+		// JSON input is decoded strictly so that YAML-only syntax is rejected
+		opts.StrictJSON = strings.HasSuffix(input, `.json`)
+		var m dgo.Value
+		var err error
+		m, ps, err = yaml.UnmarshalWithPositions(input, data, &opts)
 		if err != nil {
 			panic(catch.Error(err))
 		}
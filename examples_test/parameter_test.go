@@ -79,6 +79,21 @@ port: 1022
 	expectError(t, `parameter 'port' is not an instance of type 1..999`, validate(t, params))
 }
 
+func TestUnmarshalParameterValuesAs(t *testing.T) {
+	const sampleValues = `
+host: example.com
+port: 1022
+`
+	pt, err := loadDesc([]byte(sampleParameters))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = yaml.UnmarshalAs([]byte(sampleValues), pt)
+	if err == nil || err.Error() != `3:7: port: expected a value of type 1..999, got 1022` {
+		t.Errorf(`expected type violation for 'port', got %v`, err)
+	}
+}
+
 func validate(t *testing.T, params dgo.Value) []error {
 	t.Helper()
 	pt, err := loadDesc([]byte(sampleParameters))
@@ -0,0 +1,111 @@
+package yaml_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tada/dgo/dgo"
+	"github.com/tada/dgo/test/require"
+	"github.com/tada/dgo/vf"
+	"github.com/tada/dgoyaml/yaml"
+)
+
+func ExampleMarshalWithOptions_anchors() {
+	shared := vf.Map(`image`, `nginx`, `replicas`, 3)
+	bs, err := yaml.MarshalWithOptions(vf.Map(`dev`, shared, `prod`, shared), &yaml.EncodeOptions{Anchors: true})
+	if err == nil {
+		fmt.Print(string(bs))
+	}
+	// Output:
+	// dev: &a1
+	//     image: nginx
+	//     replicas: 3
+	// prod: *a1
+}
+
+func TestUnmarshal_alias(t *testing.T) {
+	v, err := yaml.Unmarshal([]byte(`
+defaults: &d
+  a: 1
+x: *d
+y: *d
+s: &s hello
+t: *s
+`))
+	require.NoError(t, err)
+	m := v.(dgo.Map)
+	require.Equal(t, vf.Map(`a`, 1), m.Get(`x`))
+	require.Same(t, m.Get(`defaults`), m.Get(`x`))
+	require.Same(t, m.Get(`x`), m.Get(`y`))
+	require.Equal(t, `hello`, m.Get(`t`))
+
+	m.Get(`x`).(dgo.Map).Put(`b`, 2)
+	require.Equal(t, vf.Map(`a`, 1, `b`, 2), m.Get(`y`))
+}
+
+func TestUnmarshal_alias_recursive(t *testing.T) {
+	v, err := yaml.Unmarshal([]byte(`
+a: &a [1, *a]
+m: &m {self: *m}
+`))
+	require.NoError(t, err)
+	m := v.(dgo.Map)
+	a := m.Get(`a`).(dgo.Array)
+	require.Same(t, a, a.Get(1))
+	mm := m.Get(`m`).(dgo.Map)
+	require.Same(t, mm, mm.Get(`self`))
+}
+
+func TestMarshalWithOptions_anchors(t *testing.T) {
+	shared := vf.Values(1, 2)
+	v := vf.Map(`a`, shared, `b`, vf.Map(`c`, shared, `d`, vf.Values(`x`)))
+	bs, err := yaml.MarshalWithOptions(v, &yaml.EncodeOptions{Anchors: true})
+	require.NoError(t, err)
+	require.Equal(t, `a: &a1
+  - 1
+  - 2
+b:
+    c: *a1
+    d:
+      - x
+`, string(bs))
+
+	v2, err := yaml.Unmarshal(bs)
+	require.NoError(t, err)
+	require.Equal(t, v, v2)
+	m := v2.(dgo.Map)
+	require.Same(t, m.Get(`a`), m.Get(`b`).(dgo.Map).Get(`c`))
+}
+
+func TestMarshalWithOptions_anchors_recursive(t *testing.T) {
+	type structA struct {
+		A string `yaml:"a"`
+	}
+	a := vf.MutableValues(1, nil)
+	a.Set(1, a)
+	m := vf.MutableMap(`a`, a, `s`, vf.Map(&structA{A: `x`}))
+	m.Put(`self`, m)
+	bs, err := yaml.MarshalWithOptions(m, &yaml.EncodeOptions{Anchors: true})
+	require.NoError(t, err)
+	require.Equal(t, `&a1
+a: &a2
+  - 1
+  - *a2
+s:
+    a: x
+self: *a1
+`, string(bs))
+}
+
+func TestMarshal_noAnchors(t *testing.T) {
+	shared := vf.Values(1, 2)
+	bs, err := yaml.Marshal(vf.Map(`a`, shared, `b`, shared))
+	require.NoError(t, err)
+	require.Equal(t, `a:
+  - 1
+  - 2
+b:
+  - 1
+  - 2
+`, string(bs))
+}
@@ -0,0 +1,48 @@
+package yaml
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/tada/dgo/dgo"
+)
+
+// entries returns the entries of the given map. The entries are sorted by key when the Canonical option is set.
+func (e *encoder) entries(v dgo.Map) []dgo.MapEntry {
+	es := make([]dgo.MapEntry, 0, v.Len())
+	v.EachEntry(func(me dgo.MapEntry) { es = append(es, me) })
+	if e.Canonical {
+		sort.Slice(es, func(i, j int) bool { return compareKeys(es[i].Key(), es[j].Key()) < 0 })
+	}
+	return es
+}
+
+// keyRank returns the rank used when ordering keys of different kinds
+func keyRank(v dgo.Value) int {
+	switch v.(type) {
+	case dgo.Nil:
+		return 0
+	case dgo.Boolean:
+		return 1
+	case dgo.Integer, dgo.Float:
+		return 2
+	case dgo.String:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// compareKeys imposes a total order on map keys. Keys are first ordered by kind, then by their natural
+// order, and last by their string representation.
+func compareKeys(a, b dgo.Value) int {
+	if r := keyRank(a) - keyRank(b); r != 0 {
+		return r
+	}
+	if c, ok := a.(dgo.Comparable); ok {
+		if r, ok := c.CompareTo(b); ok && r != 0 {
+			return r
+		}
+	}
+	return strings.Compare(a.String(), b.String())
+}
@@ -0,0 +1,96 @@
+package yaml_test
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/tada/dgo/dgo"
+	"github.com/tada/dgo/test/require"
+	"github.com/tada/dgo/vf"
+	"github.com/tada/dgoyaml/yaml"
+)
+
+func ExampleEncodeOptions_canonical() {
+	f := 0.1
+	v := vf.Map(`b`, f+0.2, `a`, 1e21, `c`, 2.0)
+	b, err := yaml.MarshalWithOptions(v, &yaml.EncodeOptions{Canonical: true})
+	if err == nil {
+		fmt.Print(string(b))
+	}
+	// Output:
+	// a: 1e+21
+	// b: 0.30000000000000004
+	// c: 2.0
+}
+
+func TestMarshalWithOptions_canonical_keyOrder(t *testing.T) {
+	v := vf.Map(`b`, 1, 10, 2, nil, 3, 9, 4, `a`, 5, true, 6, false, 7, 1.5, 8, vf.Values(1), 9, vf.Values(0), 10, 1.0, 11, 1, 12)
+	b, err := yaml.MarshalWithOptions(v, &yaml.EncodeOptions{Canonical: true})
+	require.NoError(t, err)
+	require.Equal(t, `null: 3
+false: 7
+true: 6
+1: 12
+1.0: 11
+1.5: 8
+9: 4
+10: 2
+a: 5
+b: 1
+? - 0
+: 10
+? - 1
+: 9
+`, string(b))
+}
+
+func TestMarshalWithOptions_canonical_equal(t *testing.T) {
+	a := vf.MutableMap()
+	a.Put(`x`, vf.Map(`q`, 1, `p`, 2))
+	a.Put(`y`, 3)
+	b := vf.MutableMap()
+	b.Put(`y`, 3)
+	b.Put(`x`, vf.Map(`p`, 2, `q`, 1))
+	require.Equal(t, a, b)
+
+	opts := &yaml.EncodeOptions{Canonical: true}
+	ab, err := yaml.MarshalWithOptions(a, opts)
+	require.NoError(t, err)
+	bb, err := yaml.MarshalWithOptions(b, opts)
+	require.NoError(t, err)
+	require.Equal(t, string(ab), string(bb))
+	require.Equal(t, "x:\n    p: 2\n    q: 1\ny: 3\n", string(ab))
+}
+
+func TestMarshalWithOptions_canonical_floats(t *testing.T) {
+	bf, _, _ := big.ParseFloat(`1e1000`, 10, 64, big.ToNearestEven)
+	v := vf.Values(1.0, -0.5, 123456789.0, 1e-7, math.Inf(1), math.Inf(-1), math.NaN(),
+		vf.BigFloat(bf), vf.BigFloat(big.NewFloat(3)), vf.BigFloat(new(big.Float).SetInf(true)))
+	b, err := yaml.MarshalWithOptions(v, &yaml.EncodeOptions{Canonical: true})
+	require.NoError(t, err)
+	require.Equal(t, `- 1.0
+- -0.5
+- 123456789.0
+- 1e-07
+- .inf
+- -.inf
+- .nan
+- !!float 1e+1000
+- 3.0
+- -.inf
+`, string(b))
+
+	rv, err := yaml.Unmarshal(b)
+	require.NoError(t, err)
+	require.Equal(t, 123456789.0, rv.(dgo.Array).Get(2))
+}
+
+func TestMarshalWithOptions_canonical_time(t *testing.T) {
+	ts, _ := time.Parse(time.RFC3339, `2019-10-06T07:15:00-07:00`)
+	b, err := yaml.MarshalWithOptions(vf.Time(ts), &yaml.EncodeOptions{Canonical: true})
+	require.NoError(t, err)
+	require.Equal(t, "!!timestamp 2019-10-06T14:15:00Z\n", string(b))
+}
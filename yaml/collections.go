@@ -0,0 +1,174 @@
+package yaml
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tada/dgo/dgo"
+	"github.com/tada/dgo/vf"
+	y3 "gopkg.in/yaml.v3"
+)
+
+// Set returns a value that is marshaled as a YAML !!set, i.e. a mapping with the given values as keys and
+// null values. It is the counterpart of the array of unique values that a !!set is decoded into.
+func Set(values dgo.Array) *Tagged {
+	return &Tagged{tag: `!!set`, value: values}
+}
+
+// OMap returns a value that is marshaled as a YAML !!omap, i.e. a sequence of single-entry mappings in the
+// order of the entries of the given map. It is the counterpart of the map that an !!omap is decoded into.
+func OMap(m dgo.Map) *Tagged {
+	return &Tagged{tag: `!!omap`, value: m}
+}
+
+// Pairs returns a value that is marshaled as a YAML !!pairs, i.e. a sequence of single-entry mappings. Each
+// element of the given array must be an array with two elements, the key and the value. It is the counterpart
+// of the array of pairs that a !!pairs is decoded into.
+func Pairs(pairs dgo.Array) *Tagged {
+	return &Tagged{tag: `!!pairs`, value: pairs}
+}
+
+// decodeSet decodes a !!set mapping node into an array that contains each key once
+func (d *decoder) decodeSet(n *y3.Node) dgo.Array {
+	if n.Kind != y3.MappingNode {
+		d.fail(n, errors.New(`a !!set must be a mapping`))
+	}
+	ms := n.Content
+	a := vf.WrapSlice(make([]dgo.Value, 0, len(ms)/2))
+	d.anchor(n, a)
+	seen := vf.MapWithCapacity(len(ms) / 2)
+	for i := 0; i < len(ms); i += 2 {
+		kn := ms[i]
+		if vn := ms[i+1]; vn.Tag != `!!null` {
+			d.fail(vn, fmt.Errorf(`the value of a !!set entry must be null, got %s`, vn.Tag))
+		}
+		d.path = append(d.path, a.Len())
+		k := d.decodeValue(kn)
+		d.path = d.path[:len(d.path)-1]
+		if seen.ContainsKey(k) {
+			if !d.AllowDuplicateKeys {
+				d.duplicateKey(k, kn, mappingKeys(ms))
+			}
+			continue
+		}
+		seen.Put(k, vf.Nil)
+		a.Add(k)
+	}
+	return a
+}
+
+// decodeOMap decodes an !!omap sequence node into a map that retains the order of the entries
+func (d *decoder) decodeOMap(n *y3.Node) dgo.Map {
+	ens := d.pairNodes(n, `an !!omap`)
+	m := vf.MapWithCapacity(len(ens))
+	d.anchor(n, m)
+	for _, en := range ens {
+		kn := en.Content[0]
+		k := d.decodeDetached(kn)
+		d.path = append(d.path, k)
+		if !d.AllowDuplicateKeys && m.ContainsKey(k) {
+			kns := make([]*y3.Node, len(ens))
+			for i, pn := range ens {
+				kns[i] = pn.Content[0]
+			}
+			d.duplicateKey(k, kn, kns)
+		}
+		if d.positions != nil {
+			d.positions.addKey(d.path, kn)
+		}
+		if d.comments != nil {
+			d.comments.addKey(d.path, kn)
+		}
+		m.Put(k, d.decodeValue(en.Content[1]))
+		d.path = d.path[:len(d.path)-1]
+	}
+	return m
+}
+
+// decodePairs decodes a !!pairs sequence node into an array of two-element arrays, each holding a key and a value
+func (d *decoder) decodePairs(n *y3.Node) dgo.Array {
+	ens := d.pairNodes(n, `a !!pairs`)
+	a := vf.WrapSlice(make([]dgo.Value, len(ens)))
+	d.anchor(n, a)
+	for i, en := range ens {
+		d.path = append(d.path, i)
+		p := vf.WrapSlice(make([]dgo.Value, 2))
+		for j, pn := range en.Content {
+			d.path = append(d.path, j)
+			p.Set(j, d.decodeValue(pn))
+			d.path = d.path[:len(d.path)-1]
+		}
+		a.Set(i, p)
+		d.path = d.path[:len(d.path)-1]
+	}
+	return a
+}
+
+// pairNodes returns the elements of the given !!omap or !!pairs node after asserting that it is a sequence of
+// single-entry mappings. The given description of the node is used in the error message.
+func (d *decoder) pairNodes(n *y3.Node, what string) []*y3.Node {
+	if n.Kind != y3.SequenceNode {
+		d.fail(n, fmt.Errorf(`%s must be a sequence of single-entry mappings`, what))
+	}
+	for _, en := range n.Content {
+		if en.Kind != y3.MappingNode || len(en.Content) != 2 {
+			d.fail(en, fmt.Errorf(`%s must be a sequence of single-entry mappings`, what))
+		}
+	}
+	return n.Content
+}
+
+// encodeCollection returns the node for a Tagged value created by Set, OMap, or Pairs, or nil if the given
+// value has some other tag
+func (e *encoder) encodeCollection(t *Tagged) *y3.Node {
+	var n *y3.Node
+	switch t.tag {
+	case `!!set`:
+		a, ok := t.value.(dgo.Array)
+		if !ok {
+			e.fail(t.tag, errors.New(`the value of a !!set must be an array`))
+		}
+		n = &y3.Node{Kind: y3.MappingNode, Content: make([]*y3.Node, 0, a.Len()*2)}
+		a.EachWithIndex(func(v dgo.Value, i int) {
+			e.path = append(e.path, i)
+			n.Content = append(n.Content, e.yamlEncodeValue(v), &y3.Node{Kind: y3.ScalarNode, Tag: `!!null`})
+			e.path = e.path[:len(e.path)-1]
+		})
+	case `!!omap`:
+		m, ok := t.value.(dgo.Map)
+		if !ok {
+			e.fail(t.tag, errors.New(`the value of an !!omap must be a map`))
+		}
+		n = &y3.Node{Kind: y3.SequenceNode, Content: make([]*y3.Node, 0, m.Len())}
+		m.EachEntry(func(me dgo.MapEntry) {
+			e.path = append(e.path, me.Key())
+			n.Content = append(n.Content, e.encodePair(me.Key(), me.Value()))
+			e.path = e.path[:len(e.path)-1]
+		})
+	case `!!pairs`:
+		a, ok := t.value.(dgo.Array)
+		if !ok {
+			e.fail(t.tag, errors.New(`the value of a !!pairs must be an array`))
+		}
+		n = &y3.Node{Kind: y3.SequenceNode, Content: make([]*y3.Node, a.Len())}
+		a.EachWithIndex(func(v dgo.Value, i int) {
+			e.path = append(e.path, i)
+			p, ok := v.(dgo.Array)
+			if !ok || p.Len() != 2 {
+				e.fail(t.tag, errors.New(`each element of a !!pairs must be an array with a key and a value`))
+			}
+			n.Content[i] = e.encodePair(p.Get(0), p.Get(1))
+			e.path = e.path[:len(e.path)-1]
+		})
+	default:
+		return nil
+	}
+	// The yaml.v3 encoder only abbreviates the long form of tags that aren't in the core schema
+	n.Tag = `tag:yaml.org,2002:` + t.tag[2:]
+	return n
+}
+
+// encodePair returns a single-entry mapping node with the given key and value
+func (e *encoder) encodePair(k, v dgo.Value) *y3.Node {
+	return &y3.Node{Kind: y3.MappingNode, Tag: `!!map`, Content: []*y3.Node{e.yamlEncodeValue(k), e.yamlEncodeValue(v)}}
+}
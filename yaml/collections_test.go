@@ -0,0 +1,157 @@
+package yaml_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tada/dgo/dgo"
+	"github.com/tada/dgo/test/require"
+	"github.com/tada/dgo/tf"
+	"github.com/tada/dgo/vf"
+	"github.com/tada/dgoyaml/yaml"
+)
+
+func ExampleSet() {
+	v, err := yaml.Unmarshal([]byte(`
+colors: !!set {red, green, blue}
+steps: !!omap [build: make, test: make test]
+`))
+	if err == nil {
+		m := v.(dgo.Map)
+		fmt.Println(m.Get(`colors`).String())
+		fmt.Println(m.Get(`steps`).String())
+
+		m.Put(`colors`, yaml.Set(m.Get(`colors`).(dgo.Array)))
+		m.Put(`steps`, yaml.OMap(m.Get(`steps`).(dgo.Map)))
+		bs, _ := yaml.Marshal(m)
+		fmt.Print(string(bs))
+	}
+	// Output:
+	// {"red","green","blue"}
+	// {"build":"make","test":"make test"}
+	// colors: !!set
+	//     red:
+	//     green:
+	//     blue:
+	// steps: !!omap
+	//   - build: make
+	//   - test: make test
+}
+
+func TestUnmarshal_set(t *testing.T) {
+	v, err := yaml.Unmarshal([]byte("!!set\n? b\n? a\n? 3\n"))
+	require.NoError(t, err)
+	require.Equal(t, vf.Values(`b`, `a`, 3), v)
+
+	v, err = yaml.Unmarshal([]byte("a: !!set &s {x, y}\nb: *s\n"))
+	require.NoError(t, err)
+	require.Equal(t, vf.Map(`a`, vf.Values(`x`, `y`), `b`, vf.Values(`x`, `y`)), v)
+	m := v.(dgo.Map)
+	require.Same(t, m.Get(`a`), m.Get(`b`))
+
+	v, err = yaml.UnmarshalWithOptions([]byte(`!!set {a, b, a}`), &yaml.DecodeOptions{AllowDuplicateKeys: true})
+	require.NoError(t, err)
+	require.Equal(t, vf.Values(`a`, `b`), v)
+
+	v, err = yaml.UnmarshalAs([]byte(`!!set {a, b}`), tf.ParseType(`[]string`))
+	require.NoError(t, err)
+	require.Equal(t, vf.Values(`a`, `b`), v)
+}
+
+func TestUnmarshal_set_fail(t *testing.T) {
+	_, err := yaml.Unmarshal([]byte(`!!set {a, b, a}`))
+	require.Equal(t, `1:14: duplicate key "a", previously defined at 1:8`, err.Error())
+
+	_, err = yaml.Unmarshal([]byte(`s: !!set {a: 1}`))
+	require.Equal(t, `1:14: s: the value of a !!set entry must be null, got !!int`, err.Error())
+
+	_, err = yaml.Unmarshal([]byte(`s: !!set [a]`))
+	require.Equal(t, `1:4: s: a !!set must be a mapping`, err.Error())
+}
+
+func TestUnmarshal_omap(t *testing.T) {
+	v, err := yaml.Unmarshal([]byte("!!omap\n- z: 1\n- a: [2]\n- m: 3\n"))
+	require.NoError(t, err)
+	require.Equal(t, vf.Map(`z`, 1, `a`, vf.Values(2), `m`, 3), v)
+	require.Equal(t, vf.Strings(`z`, `a`, `m`), v.(dgo.Map).Keys())
+
+	v, err = yaml.UnmarshalWithOptions([]byte(`!!omap [a: 1, a: 2]`), &yaml.DecodeOptions{AllowDuplicateKeys: true})
+	require.NoError(t, err)
+	require.Equal(t, vf.Map(`a`, 2), v)
+
+	_, ps, err := yaml.UnmarshalWithPositions(`test.yaml`, []byte("!!omap\n- z: 1\n- a: [2]\n"), nil)
+	require.NoError(t, err)
+	pos, _ := ps.Key(`a`)
+	require.Equal(t, `test.yaml:3:3`, pos.String())
+	pos, _ = ps.Value(`a[0]`)
+	require.Equal(t, `test.yaml:3:7`, pos.String())
+
+	doc, err := yaml.UnmarshalDocument([]byte("!!omap\n- z: 1 # the z\n"), nil)
+	require.NoError(t, err)
+	require.Equal(t, vf.Map(`z`, 1), doc.Value)
+}
+
+func TestUnmarshal_omap_fail(t *testing.T) {
+	_, err := yaml.Unmarshal([]byte(`!!omap [a: 1, b: 2, a: 3]`))
+	require.Equal(t, `1:21: duplicate key "a", previously defined at 1:9`, err.Error())
+
+	_, err = yaml.Unmarshal([]byte(`o: !!omap [{a: 1, b: 2}]`))
+	require.Equal(t, `1:12: o: an !!omap must be a sequence of single-entry mappings`, err.Error())
+
+	_, err = yaml.Unmarshal([]byte(`o: !!omap {a: 1}`))
+	require.Equal(t, `1:4: o: an !!omap must be a sequence of single-entry mappings`, err.Error())
+}
+
+func TestUnmarshal_pairs(t *testing.T) {
+	v, err := yaml.Unmarshal([]byte("!!pairs\n- a: 1\n- b: 2\n- a: 3\n"))
+	require.NoError(t, err)
+	require.Equal(t, vf.Values(vf.Values(`a`, 1), vf.Values(`b`, 2), vf.Values(`a`, 3)), v)
+
+	_, ps, err := yaml.UnmarshalWithPositions(`test.yaml`, []byte("!!pairs\n- a: 1\n- b: 2\n"), nil)
+	require.NoError(t, err)
+	pos, _ := ps.Value(`[1][1]`)
+	require.Equal(t, `test.yaml:3:6`, pos.String())
+
+	_, err = yaml.Unmarshal([]byte(`!!pairs [a]`))
+	require.Equal(t, `1:10: a !!pairs must be a sequence of single-entry mappings`, err.Error())
+}
+
+func TestMarshal_collections(t *testing.T) {
+	v := vf.Map(
+		`s`, yaml.Set(vf.Values(`a`, 1)),
+		`o`, yaml.OMap(vf.Map(`b`, 1, `a`, 2)),
+		`p`, yaml.Pairs(vf.Values(vf.Values(`a`, 1), vf.Values(`a`, 2))))
+	bs, err := yaml.MarshalWithOptions(v, &yaml.EncodeOptions{Indent: 2, Canonical: true})
+	require.NoError(t, err)
+	require.Equal(t, `o: !!omap
+- b: 1
+- a: 2
+p: !!pairs
+- a: 1
+- a: 2
+s: !!set
+  a:
+  1:
+`, string(bs))
+
+	rv, err := yaml.Unmarshal(bs)
+	require.NoError(t, err)
+	require.Equal(t, vf.Map(
+		`s`, vf.Values(`a`, 1),
+		`o`, vf.Map(`b`, 1, `a`, 2),
+		`p`, vf.Values(vf.Values(`a`, 1), vf.Values(`a`, 2))), rv)
+}
+
+func TestMarshal_collections_fail(t *testing.T) {
+	_, err := yaml.Marshal(vf.Map(`s`, yaml.NewTagged(`!!set`, `x`)))
+	require.Equal(t, `s: the value of a !!set must be an array`, err.Error())
+
+	_, err = yaml.Marshal(vf.Map(`o`, yaml.NewTagged(`!!omap`, `x`)))
+	require.Equal(t, `o: the value of an !!omap must be a map`, err.Error())
+
+	_, err = yaml.Marshal(vf.Map(`p`, yaml.NewTagged(`!!pairs`, `x`)))
+	require.Equal(t, `p: the value of a !!pairs must be an array`, err.Error())
+
+	_, err = yaml.Marshal(vf.Map(`p`, yaml.Pairs(vf.Values(vf.Values(`a`, 1), `b`))))
+	require.Equal(t, `p[1]: each element of a !!pairs must be an array with a key and a value`, err.Error())
+}
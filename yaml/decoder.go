@@ -0,0 +1,45 @@
+package yaml
+
+import (
+	"io"
+
+	"github.com/tada/dgo/dgo"
+	y3 "gopkg.in/yaml.v3"
+)
+
+// Decoder reads and decodes a stream of YAML documents into dgo.Values
+type Decoder struct {
+	d    *y3.Decoder
+	r    *limitedReader
+	opts *DecodeOptions
+}
+
+// NewDecoder returns a new Decoder that reads from the given reader
+func NewDecoder(r io.Reader) *Decoder {
+	d := &Decoder{}
+	d.r = &limitedReader{r: r, d: d}
+	d.d = y3.NewDecoder(d.r)
+	return d
+}
+
+// SetOptions sets the options to use when decoding subsequent documents
+func (d *Decoder) SetOptions(opts *DecodeOptions) {
+	d.opts = opts
+}
+
+// Decode reads the next YAML document from the stream and returns its dgo.Value representation. The
+// error io.EOF is returned when there are no more documents to read.
+func (d *Decoder) Decode() (val dgo.Value, err error) {
+	var n y3.Node
+	if err = d.d.Decode(&n); err != nil {
+		if d.r.err != nil {
+			err = d.r.err
+		} else {
+			err = syntaxError(``, err)
+		}
+		return
+	}
+	defer recoverYamlError(&err)
+	val = newDecoder(d.opts).decodeDocument(&n)
+	return
+}
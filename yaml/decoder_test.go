@@ -0,0 +1,80 @@
+package yaml_test
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/tada/dgo/dgo"
+	"github.com/tada/dgo/test/require"
+	"github.com/tada/dgo/vf"
+	"github.com/tada/dgoyaml/yaml"
+)
+
+func ExampleDecoder_Decode() {
+	d := yaml.NewDecoder(strings.NewReader(`
+a: 1
+---
+b: 2
+---
+- c
+`))
+	for {
+		v, err := d.Decode()
+		if err != nil {
+			break
+		}
+		fmt.Println(v)
+	}
+	// Output:
+	// map[a:1]
+	// map[b:2]
+	// [c]
+}
+
+func TestDecoder_Decode(t *testing.T) {
+	d := yaml.NewDecoder(strings.NewReader("a: 1\n---\n- hello\n- 3.14\n---\n"))
+	v, err := d.Decode()
+	require.NoError(t, err)
+	require.Equal(t, vf.Map(`a`, 1), v)
+	v, err = d.Decode()
+	require.NoError(t, err)
+	require.Equal(t, vf.Values(`hello`, 3.14), v)
+	v, err = d.Decode()
+	require.NoError(t, err)
+	require.Equal(t, vf.Nil, v)
+	_, err = d.Decode()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestDecoder_Decode_empty(t *testing.T) {
+	_, err := yaml.NewDecoder(strings.NewReader(``)).Decode()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestDecoder_Decode_bad_yaml(t *testing.T) {
+	d := yaml.NewDecoder(strings.NewReader("a: 1\n---\n: :\n"))
+	_, err := d.Decode()
+	require.NoError(t, err)
+	_, err = d.Decode()
+	require.Error(t, `did not find expected key`, err)
+}
+
+func TestDecoder_Decode_error(t *testing.T) {
+	d := yaml.NewDecoder(strings.NewReader("t: !!timestamp 2019-13-06T07:15:00-07:00\n"))
+	_, err := d.Decode()
+	require.Error(t, `cannot decode`, err)
+}
+
+func TestDecoder_SetOptions(t *testing.T) {
+	d := yaml.NewDecoder(strings.NewReader("1.5\n---\n2.5\n"))
+	v, err := d.Decode()
+	require.NoError(t, err)
+	require.Equal(t, vf.Float(1.5), v)
+	d.SetOptions(&yaml.DecodeOptions{BigFloats: true})
+	v, err = d.Decode()
+	require.NoError(t, err)
+	_, ok := v.(dgo.BigFloat)
+	require.True(t, ok)
+}
@@ -0,0 +1,130 @@
+package yaml
+
+import (
+	"github.com/tada/dgo/dgo"
+	"github.com/tada/dgo/vf"
+	y3 "gopkg.in/yaml.v3"
+)
+
+// Document is a decoded YAML document that retains the comments of its source. The comments are attached
+// to the paths of the values in the document (see Positions for a description of paths), so the Value can
+// be modified and then marshaled with the comments of all retained map keys and array elements intact.
+type Document struct {
+	// Value is the value of the document
+	Value dgo.Value
+
+	comments *comments
+}
+
+// UnmarshalDocument decodes the YAML representation of the given bytes into a Document using the given
+// options. A nil options pointer gives the default behavior.
+func UnmarshalDocument(b []byte, opts *DecodeOptions) (doc *Document, err error) {
+	if err = checkInputSize(``, b, opts); err != nil {
+		return
+	}
+	var n y3.Node
+	if err = y3.Unmarshal(b, &n); err != nil {
+		err = syntaxError(``, err)
+		return
+	}
+	defer recoverYamlError(&err)
+	d := newDecoder(opts)
+	d.comments = newComments()
+	d.comments.document = commentsOf(&n)
+	doc = &Document{Value: d.decodeDocument(&n), comments: d.comments}
+	return
+}
+
+// Marshal returns the YAML representation of the document, including its comments
+func (doc *Document) Marshal() ([]byte, error) {
+	return doc.MarshalWithOptions(nil)
+}
+
+// MarshalWithOptions returns the YAML representation of the document, including its comments, using the
+// given options. A nil options pointer gives the same result as Marshal.
+func (doc *Document) MarshalWithOptions(opts *EncodeOptions) (bytes []byte, err error) {
+	defer recoverYamlError(&err)
+	e := newEncoder(opts)
+	n := e.encode(doc.Value)
+	cs := doc.comments
+	if cs == nil {
+		cs = newComments()
+	}
+	cs.apply(nil, n)
+	dn := &y3.Node{Kind: y3.DocumentNode, Content: []*y3.Node{n}}
+	cs.document.setOn(dn)
+	bytes, err = e.marshal(dn)
+	return
+}
+
+// comment holds the comments that are attached to one node
+type comment struct {
+	head string
+	line string
+	foot string
+}
+
+func commentsOf(n *y3.Node) comment {
+	return comment{head: n.HeadComment, line: n.LineComment, foot: n.FootComment}
+}
+
+func (c comment) setOn(n *y3.Node) {
+	n.HeadComment = c.head
+	n.LineComment = c.line
+	n.FootComment = c.foot
+}
+
+// comments holds the comments of a document, keyed by value path
+type comments struct {
+	document comment
+	values   map[string]comment
+	keys     map[string]comment
+}
+
+func newComments() *comments {
+	return &comments{values: make(map[string]comment), keys: make(map[string]comment)}
+}
+
+func (cs *comments) addValue(path []interface{}, n *y3.Node) {
+	if c := commentsOf(n); c != (comment{}) {
+		cs.values[pathString(path)] = c
+	}
+}
+
+func (cs *comments) addKey(path []interface{}, n *y3.Node) {
+	if c := commentsOf(n); c != (comment{}) {
+		cs.keys[pathString(path)] = c
+	}
+}
+
+// nodeKey returns the map key that the given key node of an encoded value represents. String keys are taken
+// as is since the encoder leaves the quoting of strings that would resolve to other values to the emitter.
+func nodeKey(kn *y3.Node) dgo.Value {
+	if kn.Kind == y3.ScalarNode && kn.Tag == `!!str` {
+		return vf.String(kn.Value)
+	}
+	return (&decoder{}).decodeDetached(kn)
+}
+
+// apply attaches the recorded comments to the given node and its contents, where path is the path of the node
+func (cs *comments) apply(path []interface{}, n *y3.Node) {
+	if c, ok := cs.values[pathString(path)]; ok {
+		c.setOn(n)
+	}
+	switch n.Kind {
+	case y3.SequenceNode:
+		for i, e := range n.Content {
+			cs.apply(append(path, i), e)
+		}
+	case y3.MappingNode:
+		ms := n.Content
+		for i := 0; i < len(ms); i += 2 {
+			kn := ms[i]
+			kp := append(path, nodeKey(kn))
+			if c, ok := cs.keys[pathString(kp)]; ok {
+				c.setOn(kn)
+			}
+			cs.apply(kp, ms[i+1])
+		}
+	}
+}
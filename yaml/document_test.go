@@ -0,0 +1,127 @@
+package yaml_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tada/dgo/dgo"
+	"github.com/tada/dgo/test/require"
+	"github.com/tada/dgo/vf"
+	"github.com/tada/dgoyaml/yaml"
+)
+
+func ExampleUnmarshalDocument() {
+	doc, err := yaml.UnmarshalDocument([]byte(`# Service parameters
+host: example.com # the host
+# the port to use
+port: 22
+`), nil)
+	if err == nil {
+		doc.Value.(dgo.Map).Put(`port`, 2222)
+		bs, _ := doc.Marshal()
+		fmt.Print(string(bs))
+	}
+	// Output:
+	// # Service parameters
+	// host: example.com # the host
+	// # the port to use
+	// port: 2222
+}
+
+func TestUnmarshalDocument(t *testing.T) {
+	doc, err := yaml.UnmarshalDocument([]byte(`# doc head
+
+# head a
+a: 1 # line a
+# foot a
+
+b: # line b
+  # head c
+  c: x
+  d: [1, 2] # line d
+e:
+  # head e0
+  - one # line e0
+  - two
+`), nil)
+	require.NoError(t, err)
+	require.Equal(t, vf.Map(`a`, 1, `b`, vf.Map(`c`, `x`, `d`, vf.Values(1, 2)), `e`, vf.Values(`one`, `two`)), doc.Value)
+
+	m := doc.Value.(dgo.Map)
+	m.Put(`a`, 2)
+	m.Get(`e`).(dgo.Array).Set(0, `three`)
+	m.Remove(`b`)
+	bs, err := doc.Marshal()
+	require.NoError(t, err)
+	require.Equal(t, `# doc head
+
+# head a
+a: 2 # line a
+# foot a
+
+e:
+  # head e0
+  - three # line e0
+  - two
+`, string(bs))
+}
+
+func TestUnmarshalDocument_ambiguousKeys(t *testing.T) {
+	src := `a.b: 1 # top
+a:
+  b: 2 # nested
+8080: 3 # int
+"8080": 4 # string
+`
+	doc, err := yaml.UnmarshalDocument([]byte(src), nil)
+	require.NoError(t, err)
+	bs, err := doc.Marshal()
+	require.NoError(t, err)
+	require.Equal(t, `a.b: 1 # top
+a:
+    b: 2 # nested
+8080: 3 # int
+"8080": 4 # string
+`, string(bs))
+}
+
+func TestUnmarshalDocument_bad_yaml(t *testing.T) {
+	_, err := yaml.UnmarshalDocument([]byte(": :\n"), nil)
+	require.Error(t, `did not find expected key`, err)
+}
+
+func TestUnmarshalDocument_error(t *testing.T) {
+	_, err := yaml.UnmarshalDocument([]byte("t: !!timestamp 2019-13-06T07:15:00-07:00\n"), nil)
+	require.Error(t, `cannot decode`, err)
+}
+
+func TestDocument_Marshal_noComments(t *testing.T) {
+	doc := &yaml.Document{Value: vf.Map(`a`, 1)}
+	bs, err := doc.Marshal()
+	require.NoError(t, err)
+	require.Equal(t, "a: 1\n", string(bs))
+}
+
+func TestDocument_Marshal_fail(t *testing.T) {
+	doc := &yaml.Document{Value: vf.MutableValues(&marshalTestFail{})}
+	_, err := doc.Marshal()
+	require.Equal(t, `[0]: errFailing`, err.Error())
+}
+
+func TestDocument_MarshalWithOptions_style(t *testing.T) {
+	doc, err := yaml.UnmarshalDocument([]byte(`# the ports
+ports:
+    - 80 # http
+    - 443
+name: x
+`), nil)
+	require.NoError(t, err)
+	b, err := doc.MarshalWithOptions(&yaml.EncodeOptions{Indent: 2, Quote: yaml.QuoteDouble})
+	require.NoError(t, err)
+	require.Equal(t, `# the ports
+ports:
+- 80 # http
+- 443
+name: "x"
+`, string(b))
+}
@@ -0,0 +1,45 @@
+package yaml
+
+import (
+	"io"
+
+	"github.com/tada/dgo/dgo"
+	y3 "gopkg.in/yaml.v3"
+)
+
+// Encoder writes dgo.Values as a stream of YAML documents
+type Encoder struct {
+	e    *y3.Encoder
+	opts *EncodeOptions
+}
+
+// NewEncoder returns a new Encoder that writes to the given writer
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{e: y3.NewEncoder(w)}
+}
+
+// SetOptions sets the options to use when encoding subsequent documents. The options are validated by Encode.
+// The indentation is shared by all documents in the stream so the Indent option has no effect once the first
+// document has been encoded.
+func (e *Encoder) SetOptions(opts *EncodeOptions) {
+	e.opts = opts
+}
+
+// Encode writes the YAML representation of the given value to the stream. Documents after the first
+// are preceded by a "---" document separator.
+func (e *Encoder) Encode(v dgo.Value) (err error) {
+	defer recoverYamlError(&err)
+	n := newEncoder(e.opts).encode(v)
+
+	// the indent is applied after encode has validated it since the YAML encoder panics on a negative indent
+	if e.opts != nil {
+		e.e.SetIndent(e.opts.Indent)
+	}
+	err = e.e.Encode(n)
+	return
+}
+
+// Close flushes any remaining data to the stream. It does not close the underlying writer.
+func (e *Encoder) Close() error {
+	return e.e.Close()
+}
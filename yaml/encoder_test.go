@@ -0,0 +1,87 @@
+package yaml_test
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/tada/dgo/test/require"
+	"github.com/tada/dgo/vf"
+	"github.com/tada/dgoyaml/yaml"
+)
+
+func ExampleEncoder_Encode() {
+	e := yaml.NewEncoder(os.Stdout)
+	_ = e.Encode(vf.Map(`a`, 1))
+	_ = e.Encode(vf.Map(`b`, 2))
+	_ = e.Encode(vf.Values(`c`))
+	_ = e.Close()
+	// Output:
+	// a: 1
+	// ---
+	// b: 2
+	// ---
+	// - c
+}
+
+func TestEncoder_Encode(t *testing.T) {
+	sb := &strings.Builder{}
+	e := yaml.NewEncoder(sb)
+	require.NoError(t, e.Encode(vf.Map(`a`, 1, `b`, vf.Values(`hello`, 3.14))))
+	require.NoError(t, e.Encode(vf.String(`two`)))
+	require.NoError(t, e.Close())
+	require.Equal(t, "a: 1\nb:\n  - hello\n  - 3.14\n---\ntwo\n", sb.String())
+
+	v, err := yaml.NewDecoder(strings.NewReader(sb.String())).Decode()
+	require.NoError(t, err)
+	require.Equal(t, vf.Map(`a`, 1, `b`, vf.Values(`hello`, 3.14)), v)
+}
+
+func TestEncoder_Encode_fail(t *testing.T) {
+	sb := &strings.Builder{}
+	e := yaml.NewEncoder(sb)
+	require.NoError(t, e.Encode(vf.Map(`a`, 1)))
+	err := e.Encode(vf.MutableValues(&marshalTestFail{}))
+	require.Equal(t, `[0]: errFailing`, err.Error())
+	require.NoError(t, e.Encode(vf.Map(`b`, 2)))
+	require.NoError(t, e.Close())
+	require.Equal(t, "a: 1\n---\nb: 2\n", sb.String())
+}
+
+func TestEncoder_SetOptions(t *testing.T) {
+	sb := &strings.Builder{}
+	e := yaml.NewEncoder(sb)
+	e.SetOptions(&yaml.EncodeOptions{Anchors: true})
+	shared := vf.Values(1)
+	require.NoError(t, e.Encode(vf.Map(`a`, shared, `b`, shared)))
+	require.NoError(t, e.Close())
+	require.Equal(t, "a: &a1\n  - 1\nb: *a1\n", sb.String())
+}
+
+func TestEncoder_SetOptions_style(t *testing.T) {
+	sb := &strings.Builder{}
+	e := yaml.NewEncoder(sb)
+	e.SetOptions(&yaml.EncodeOptions{Indent: 2, FlowLength: 3, Quote: yaml.QuoteDouble})
+	require.NoError(t, e.Encode(vf.Map(`a`, vf.Map(`b`, vf.Values(1, 2, 3, 4)), `c`, vf.Values(`x`))))
+	require.NoError(t, e.Close())
+	require.Equal(t, "a:\n  b:\n  - 1\n  - 2\n  - 3\n  - 4\nc: [\"x\"]\n", sb.String())
+}
+
+func TestEncoder_SetOptions_invalid(t *testing.T) {
+	sb := &strings.Builder{}
+	e := yaml.NewEncoder(sb)
+	e.SetOptions(&yaml.EncodeOptions{Indent: -1})
+	err := e.Encode(vf.Map(`a`, 1))
+	require.Equal(t, `indent must be between 2 and 9, got -1`, err.Error())
+	var ye *yaml.Error
+	require.True(t, errors.As(err, &ye))
+
+	e.SetOptions(&yaml.EncodeOptions{Quote: yaml.QuoteDouble + 1})
+	require.Equal(t, `invalid quote style 3`, e.Encode(vf.Map(`a`, 1)).Error())
+
+	e.SetOptions(&yaml.EncodeOptions{Indent: 2})
+	require.NoError(t, e.Encode(vf.Map(`a`, vf.Values(1))))
+	require.NoError(t, e.Close())
+	require.Equal(t, "a:\n- 1\n", sb.String())
+}
@@ -0,0 +1,78 @@
+package yaml
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	y3 "gopkg.in/yaml.v3"
+)
+
+// Error is returned when a document cannot be parsed, when a value cannot be decoded or encoded, or when the
+// encode options are invalid. It tells where the failure happened and wraps the error that caused it. Failures that are reported using a more
+// specific error type, such as *ValidationError, *DuplicateKeyError, or *LimitError, are not wrapped in an Error.
+type Error struct {
+	// Path is the path of the offending value, see Positions for a description of paths
+	Path string
+
+	// Position is the position of the offending node in the YAML source. The column is zero for syntax errors
+	// and the Position is the zero Position when the error occurred during encoding.
+	Position Position
+
+	// Tag is the tag of the offending node, or the empty string when the tag is unknown
+	Tag string
+
+	// Cause is the underlying error
+	Cause error
+}
+
+// Error returns the message of the cause, prefixed by the position and the path of the offending value when
+// they are known
+func (e *Error) Error() string {
+	sb := strings.Builder{}
+	if e.Position.Line > 0 {
+		sb.WriteString(e.Position.String())
+		sb.WriteString(`: `)
+	}
+	if e.Path != `` {
+		sb.WriteString(e.Path)
+		sb.WriteString(`: `)
+	}
+	sb.WriteString(e.Cause.Error())
+	return sb.String()
+}
+
+// Unwrap returns the cause of the error
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// fail panics with an *Error that describes a failure to decode the given node
+func (d *decoder) fail(n *y3.Node, err error) {
+	panic(yamlError{&Error{Path: pathString(d.path), Position: d.position(n), Tag: n.Tag, Cause: err}})
+}
+
+// fail panics with an *Error that describes a failure to encode the value at the current path
+func (e *encoder) fail(tag string, err error) {
+	panic(yamlError{&Error{Path: pathString(e.path), Tag: tag, Cause: err}})
+}
+
+// syntaxErrorPattern matches the message of a syntax error that the YAML parser reports for a given line
+var syntaxErrorPattern = regexp.MustCompile(`(?s)\Ayaml: line ([0-9]+): (.*)\z`)
+
+// syntaxError returns an *Error that wraps the given error from the YAML parser. The error is positioned at the
+// line given in its message when there is one. The io.EOF that marks the end of a stream is returned as is.
+func syntaxError(file string, err error) error {
+	if err == io.EOF {
+		return err
+	}
+	ye := &Error{Cause: err}
+	if m := syntaxErrorPattern.FindStringSubmatch(err.Error()); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		ye.Position = Position{File: file, Line: line}
+		ye.Cause = errors.New(m[2])
+	}
+	return ye
+}
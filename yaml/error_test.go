@@ -0,0 +1,98 @@
+package yaml_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/tada/dgo/test/require"
+	"github.com/tada/dgo/vf"
+	"github.com/tada/dgoyaml/yaml"
+)
+
+func ExampleError() {
+	_, err := yaml.Unmarshal([]byte(`
+c:
+  - name: a
+  - name: !!int 0x1g
+`))
+	var ye *yaml.Error
+	if errors.As(err, &ye) {
+		fmt.Println(ye.Path)
+		fmt.Println(ye.Position)
+		fmt.Println(ye.Tag)
+		fmt.Println(ye.Cause)
+	}
+	// Output:
+	// c[1].name
+	// 4:11
+	// !!int
+	// cannot decode !!int `0x1g` as an integer
+}
+
+func TestError_decode(t *testing.T) {
+	tests := []struct {
+		source string
+		msg    string
+		path   string
+		tag    string
+	}{
+		{"a: !!int x", "1:4: a: cannot decode !!int `x` as an integer", `a`, `!!int`},
+		{"a: [!!float x]", "1:5: a\\[0\\]: yaml: cannot decode !!str `x` as a !!float", `a[0]`, `!!float`},
+		{"!!timestamp x", "1:1: yaml: cannot decode !!str `x` as a !!timestamp", ``, `!!timestamp`},
+		{"a: {<<: 1}", `1:9: a: map merge requires a map or a sequence of maps as the value`, `a`, `!!int`},
+		{"a: {b: !duration x}", `1:8: a.b: time: invalid duration`, `a.b`, `!duration`},
+	}
+	for _, tt := range tests {
+		_, err := yaml.Unmarshal([]byte(tt.source))
+		require.Error(t, tt.msg, err)
+		var ye *yaml.Error
+		require.True(t, errors.As(err, &ye))
+		require.Equal(t, tt.path, ye.Path)
+		require.Equal(t, tt.tag, ye.Tag)
+	}
+}
+
+func TestError_decode_file(t *testing.T) {
+	_, _, err := yaml.UnmarshalWithPositions(`x.yaml`, []byte("a: !!int x"), nil)
+	require.Error(t, "x.yaml:1:4: a: cannot decode", err)
+}
+
+func TestError_encode(t *testing.T) {
+	_, err := yaml.Marshal(vf.Map(`a`, vf.Values(1, vf.Map(`b`, vf.Value(func() {})))))
+	require.Equal(t, `a[1].b: unable to marshal into value of type func()`, err.Error())
+	var ye *yaml.Error
+	require.True(t, errors.As(err, &ye))
+	require.Equal(t, `a[1].b`, ye.Path)
+	require.Equal(t, yaml.Position{}, ye.Position)
+	require.Equal(t, ``, ye.Tag)
+
+	_, err = yaml.Marshal(vf.Value(func() {}))
+	require.Equal(t, `unable to marshal into value of type func()`, err.Error())
+}
+
+func TestError_syntax(t *testing.T) {
+	_, err := yaml.Unmarshal([]byte("a:\n\tb: 1\n"))
+	require.Equal(t, `2: found character that cannot start any token`, err.Error())
+	var ye *yaml.Error
+	require.True(t, errors.As(err, &ye))
+	require.Equal(t, yaml.Position{Line: 2}, ye.Position)
+	require.Equal(t, ``, ye.Path)
+
+	_, _, err = yaml.UnmarshalWithPositions(`x.yaml`, []byte("a: [1\n"), nil)
+	require.Equal(t, `x.yaml:1: did not find expected ',' or ']'`, err.Error())
+	require.True(t, errors.As(err, &ye))
+
+	_, err = yaml.UnmarshalDocument([]byte(": :\n"), nil)
+	require.Equal(t, `yaml: did not find expected key`, err.Error())
+	require.True(t, errors.As(err, &ye))
+	require.Equal(t, yaml.Position{}, ye.Position)
+
+	_, err = yaml.NewDecoder(strings.NewReader("a: 'x")).Decode()
+	require.True(t, errors.As(err, &ye))
+
+	_, err = yaml.NewDecoder(strings.NewReader(``)).Decode()
+	require.Equal(t, io.EOF, err)
+}
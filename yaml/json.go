@@ -0,0 +1,138 @@
+package yaml
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tada/dgo/dgo"
+	"github.com/tada/dgo/vf"
+	y3 "gopkg.in/yaml.v3"
+)
+
+// quotedStyles are the styles that make a scalar a string regardless of its tag
+const quotedStyles = y3.SingleQuotedStyle | y3.DoubleQuotedStyle | y3.LiteralStyle | y3.FoldedStyle
+
+// MarshalJSON returns the JSON representation of the given value. Values are converted in the same way as by
+// Marshal and the result is then written as JSON. Maps become objects with their keys in the order of the map,
+// binaries become base64 encoded strings, types become their dgo string form, and times become RFC3339 strings.
+// Sets created by Set become arrays and other tagged values are written without their tag.
+//
+// Map keys that aren't strings are written using their string form. An *Error is returned for maps with keys
+// that are arrays or maps, and for infinite and NaN floats.
+func MarshalJSON(v dgo.Value) ([]byte, error) {
+	return MarshalJSONWithOptions(v, nil)
+}
+
+// MarshalJSONWithOptions returns the JSON representation of the given value using the given options. The
+// output is compact unless the Indent option is set. The Anchors, FlowLength, Quote, and YAML11 options have
+// no effect on JSON. A nil options pointer gives the same result as MarshalJSON.
+func MarshalJSONWithOptions(v dgo.Value, opts *EncodeOptions) (bs []byte, err error) {
+	defer recoverYamlError(&err)
+	e := newEncoder(opts)
+	e.Anchors = false
+	n := e.encode(v)
+	b := &bytes.Buffer{}
+	e.writeJSON(b, n)
+	if e.Indent > 0 {
+		ib := &bytes.Buffer{}
+		_ = json.Indent(ib, b.Bytes(), ``, strings.Repeat(` `, e.Indent))
+		b = ib
+	}
+	bs = b.Bytes()
+	return
+}
+
+// writeJSON writes the JSON representation of the given node to the given buffer
+func (e *encoder) writeJSON(b *bytes.Buffer, n *y3.Node) {
+	switch n.Kind {
+	case y3.SequenceNode:
+		e.writeJSONArray(b, n.Content, 1)
+	case y3.MappingNode:
+		if n.Tag == `tag:yaml.org,2002:set` {
+			e.writeJSONArray(b, n.Content, 2)
+			return
+		}
+		b.WriteByte('{')
+		ms := n.Content
+		for i := 0; i < len(ms); i += 2 {
+			kn := ms[i]
+			if kn.Kind != y3.ScalarNode {
+				e.fail(kn.Tag, errors.New(`a map key must be a scalar to be written as JSON`))
+			}
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeJSONString(b, kn.Value)
+			b.WriteByte(':')
+			e.path = append(e.path, vf.String(kn.Value))
+			e.writeJSON(b, ms[i+1])
+			e.path = e.path[:len(e.path)-1]
+		}
+		b.WriteByte('}')
+	default:
+		e.writeJSONScalar(b, n)
+	}
+}
+
+// writeJSONArray writes every step'th of the given nodes as a JSON array to the given buffer
+func (e *encoder) writeJSONArray(b *bytes.Buffer, ns []*y3.Node, step int) {
+	b.WriteByte('[')
+	for i := 0; i < len(ns); i += step {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		e.path = append(e.path, i/step)
+		e.writeJSON(b, ns[i])
+		e.path = e.path[:len(e.path)-1]
+	}
+	b.WriteByte(']')
+}
+
+// writeJSONScalar writes the JSON representation of the given scalar node to the given buffer
+func (e *encoder) writeJSONScalar(b *bytes.Buffer, n *y3.Node) {
+	if n.Style&quotedStyles != 0 {
+		writeJSONString(b, n.Value)
+		return
+	}
+	if n.Tag != `!puppet.com,2019:dgo/type` && !strings.HasPrefix(n.Tag, `!!`) {
+		// The content of a scalar with a custom tag is written as the value that it resolves to without the tag
+		tn := &y3.Node{Kind: y3.ScalarNode, Value: n.Value}
+		tn.Tag = tn.ShortTag()
+		n = tn
+	}
+	switch n.Tag {
+	case `!!null`, `!!bool`, `!!int`, `!!float`:
+		// YAML forms such as True, 0x1F, or 1_000 aren't valid JSON, so the scalar is decoded and then written
+		// in the form that the encoder gives the decoded value
+		d := newDecoder(nil)
+		d.path = e.path
+		n = e.yamlEncodeValue(d.decodeScalar(n))
+	}
+	switch n.Tag {
+	case `!!null`:
+		b.WriteString(`null`)
+	case `!!float`:
+		switch n.Value {
+		case `.nan`, `.inf`, `-.inf`:
+			e.fail(n.Tag, fmt.Errorf(`the float %s cannot be written as JSON`, n.Value))
+		}
+		b.WriteString(n.Value)
+	case `!!bool`, `!!int`:
+		b.WriteString(n.Value)
+	default:
+		writeJSONString(b, n.Value)
+	}
+}
+
+// writeJSONString writes the given string as a quoted JSON string to the given buffer
+func writeJSONString(b *bytes.Buffer, s string) {
+	je := json.NewEncoder(b)
+	je.SetEscapeHTML(false)
+	_ = je.Encode(s)
+
+	// strip the newline added by the encoder
+	b.Truncate(b.Len() - 1)
+}
@@ -0,0 +1,128 @@
+package yaml_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/tada/dgo/dgo"
+	"github.com/tada/dgo/test/require"
+	"github.com/tada/dgo/tf"
+	"github.com/tada/dgo/vf"
+	"github.com/tada/dgoyaml/yaml"
+	y3 "gopkg.in/yaml.v3"
+)
+
+type jsonTestNode struct {
+	tag   string
+	value string
+}
+
+func (n *jsonTestNode) MarshalYAML() (interface{}, error) {
+	return &y3.Node{Kind: y3.ScalarNode, Tag: n.tag, Value: n.value}, nil
+}
+
+func ExampleMarshalJSON() {
+	v := vf.Map(
+		`name`, `example`,
+		`ports`, vf.Values(22, 80),
+		`logo`, vf.Binary([]byte{1, 2, 3}, false),
+		`type`, tf.ParseType(`map[string]int`),
+		`created`, time.Date(2020, 4, 1, 12, 30, 0, 0, time.UTC))
+	bs, err := yaml.MarshalJSON(v)
+	if err == nil {
+		fmt.Println(string(bs))
+	}
+	// Output: {"name":"example","ports":[22,80],"logo":"AQID","type":"map[string]int","created":"2020-04-01T12:30:00Z"}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	bi, _ := new(big.Int).SetString(`123456789012345678901234567890`, 10)
+	bs, err := yaml.MarshalJSON(vf.Map(
+		`z`, nil,
+		`a`, vf.Values(true, false, 1, 2.5, 3.0, `<x & y>`, "two\nlines"),
+		1, `int key`,
+		`big`, vf.BigInt(bi)))
+	require.NoError(t, err)
+	require.Equal(t,
+		`{"z":null,"a":[true,false,1,2.5,3,"<x & y>","two\nlines"],"1":"int key","big":123456789012345678901234567890}`,
+		string(bs))
+
+	bs, err = yaml.MarshalJSONWithOptions(vf.Map(`b`, 3.0, `a`, vf.Values(1)), &yaml.EncodeOptions{Indent: 2, Canonical: true})
+	require.NoError(t, err)
+	require.Equal(t, "{\n  \"a\": [\n    1\n  ],\n  \"b\": 3.0\n}", string(bs))
+}
+
+func TestMarshalJSON_tagged(t *testing.T) {
+	v, err := yaml.Unmarshal([]byte(`
+ref: !Ref bucket
+num: !Num 0x1f
+flt: !Flt .5
+str: !Str '12'
+seq: !Seq [a, 1]
+map: !Map {a: 1}
+dur: !duration 1h
+set: !!set {b, a}
+omap: !!omap [b: 1, a: 2]
+`))
+	require.NoError(t, err)
+	m := v.(dgo.Map)
+	m.Put(`set`, yaml.Set(m.Get(`set`).(dgo.Array)))
+	m.Put(`omap`, yaml.OMap(m.Get(`omap`).(dgo.Map)))
+	bs, err := yaml.MarshalJSON(m)
+	require.NoError(t, err)
+	require.Equal(t,
+		`{"ref":"bucket","num":31,"flt":0.5,"str":"12","seq":["a",1],"map":{"a":1},"dur":"1h0m0s",`+
+			`"set":["b","a"],"omap":[{"b":1},{"a":2}]}`,
+		string(bs))
+}
+
+func TestMarshalJSON_fail(t *testing.T) {
+	_, err := yaml.MarshalJSON(vf.Map(`a`, vf.Values(math.Inf(1))))
+	require.Equal(t, `a[0]: the float .inf cannot be written as JSON`, err.Error())
+
+	_, err = yaml.MarshalJSON(vf.Map(`a`, vf.Map(vf.Values(1), 2)))
+	require.Equal(t, `a: a map key must be a scalar to be written as JSON`, err.Error())
+
+	_, err = yaml.MarshalJSON(vf.Map(`a`, vf.Value(func() {})))
+	require.Equal(t, `a: unable to marshal into value of type func()`, err.Error())
+
+	_, err = yaml.MarshalJSONWithOptions(vf.Nil, &yaml.EncodeOptions{Indent: 1})
+	require.Equal(t, `indent must be between 2 and 9, got 1`, err.Error())
+}
+
+func TestMarshalJSON_yamlForms(t *testing.T) {
+	tests := []struct {
+		tag   string
+		value string
+		json  string
+	}{
+		{`!!int`, `0x1F`, `31`},
+		{`!!int`, `0o17`, `15`},
+		{`!!int`, `1_000`, `1000`},
+		{`!!int`, `+12`, `12`},
+		{`!!bool`, `True`, `true`},
+		{`!!bool`, `FALSE`, `false`},
+		{`!!float`, `1_000.5`, `1000.5`},
+		{`!!float`, `+.5`, `0.5`},
+		{`!!null`, `~`, `null`},
+	}
+	for _, tt := range tests {
+		bs, err := yaml.MarshalJSON(vf.Map(`a`, vf.Value(&jsonTestNode{tag: tt.tag, value: tt.value})))
+		require.NoError(t, err)
+		require.Equal(t, `{"a":`+tt.json+`}`, string(bs))
+		require.True(t, json.Valid(bs))
+	}
+
+	_, err := yaml.MarshalJSON(vf.Map(`a`, vf.Value(&jsonTestNode{tag: `!!float`, value: `+.inf`})))
+	require.Equal(t, `a: the float .inf cannot be written as JSON`, err.Error())
+
+	_, err = yaml.MarshalJSON(vf.Map(`a`, vf.Value(&jsonTestNode{tag: `!!float`, value: `.NaN`})))
+	require.Equal(t, `a: the float .nan cannot be written as JSON`, err.Error())
+
+	_, err = yaml.MarshalJSON(vf.Map(`a`, vf.Value(&jsonTestNode{tag: `!!int`, value: `abc`})))
+	require.Equal(t, "a: cannot decode !!int `abc` as an integer", err.Error())
+}
@@ -0,0 +1,160 @@
+package yaml
+
+import (
+	"io"
+	"strconv"
+
+	y3 "gopkg.in/yaml.v3"
+)
+
+// LimitError is returned when the input exceeds one of the limits given by the MaxInputSize, MaxDepth, MaxNodes,
+// MaxScalarLength, or MaxAliasExpansion decode options
+type LimitError struct {
+	// Limit is the name of the exceeded limit, i.e. "input size", "depth", "node count", "scalar length", or
+	// "alias expansion"
+	Limit string
+
+	// Max is the value of the exceeded limit
+	Max int
+
+	// Position is the position of the node where the limit was exceeded. Only the file is known when the input
+	// size is exceeded.
+	Position Position
+}
+
+// Error returns a message that contains the position, the name, and the value of the exceeded limit
+func (e *LimitError) Error() string {
+	msg := e.Limit + ` exceeds the maximum of ` + strconv.Itoa(e.Max)
+	switch {
+	case e.Position.Line > 0:
+		msg = e.Position.String() + `: ` + msg
+	case e.Position.File != ``:
+		msg = e.Position.File + `: ` + msg
+	}
+	return msg
+}
+
+// checkInputSize returns a *LimitError when the given input is larger than the MaxInputSize of the given options
+// allows. The check is performed before the input is parsed.
+func checkInputSize(file string, b []byte, opts *DecodeOptions) error {
+	if opts != nil && opts.MaxInputSize > 0 && len(b) > opts.MaxInputSize {
+		return &LimitError{Limit: `input size`, Max: opts.MaxInputSize, Position: Position{File: file}}
+	}
+	return nil
+}
+
+// limitedReader is the reader of a Decoder. It fails with a *LimitError when the number of bytes read from the
+// stream exceeds the MaxInputSize of the options of the Decoder.
+type limitedReader struct {
+	r io.Reader
+	d *Decoder
+
+	// n is the number of bytes read so far
+	n int
+
+	// err is the error returned when the limit was exceeded
+	err *LimitError
+}
+
+// Read reads from the underlying reader and counts the bytes read
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	n, err := lr.r.Read(p)
+	lr.n += n
+	if opts := lr.d.opts; opts != nil && opts.MaxInputSize > 0 && lr.n > opts.MaxInputSize {
+		lr.err = &LimitError{Limit: `input size`, Max: opts.MaxInputSize}
+		return n, lr.err
+	}
+	return n, err
+}
+
+// limiter checks a document against the limits of the decode options
+type limiter struct {
+	*decoder
+
+	// nodes is the number of nodes seen so far
+	nodes int
+
+	// expanded is the number of nodes that the aliases seen so far expand to
+	expanded int
+
+	// sizes holds the expanded size of each node that is the target of an alias
+	sizes map[*y3.Node]int
+}
+
+// checkLimits panics with a *LimitError if the given document node exceeds the limits of the decode options. The
+// check is performed after the document has been parsed and before it is decoded.
+func (d *decoder) checkLimits(n *y3.Node) {
+	if d.MaxDepth > 0 || d.MaxNodes > 0 || d.MaxScalarLength > 0 || d.MaxAliasExpansion > 0 {
+		l := &limiter{decoder: d, sizes: make(map[*y3.Node]int)}
+		l.check(n, 0)
+	}
+}
+
+// check checks the given node and its descendants. The depth is the number of sequences and mappings that
+// contain the node.
+func (l *limiter) check(n *y3.Node, depth int) {
+	if n.Kind == y3.DocumentNode {
+		l.check(n.Content[0], depth)
+		return
+	}
+	l.nodes++
+	if l.MaxNodes > 0 && l.nodes > l.MaxNodes {
+		l.exceeded(n, `node count`, l.MaxNodes)
+	}
+	switch n.Kind {
+	case y3.SequenceNode, y3.MappingNode:
+		depth++
+		if l.MaxDepth > 0 && depth > l.MaxDepth {
+			l.exceeded(n, `depth`, l.MaxDepth)
+		}
+		if _, ok := l.sizes[n]; !ok && l.MaxAliasExpansion > 0 {
+			// mark the node as enclosing so that aliases to it count as one node
+			l.sizes[n] = -1
+			defer delete(l.sizes, n)
+		}
+		for _, c := range n.Content {
+			l.check(c, depth)
+		}
+	case y3.AliasNode:
+		if l.MaxAliasExpansion > 0 {
+			l.expanded += l.size(n.Alias)
+			if l.expanded > l.MaxAliasExpansion {
+				l.exceeded(n, `alias expansion`, l.MaxAliasExpansion)
+			}
+		}
+	default:
+		if l.MaxScalarLength > 0 && len(n.Value) > l.MaxScalarLength {
+			l.exceeded(n, `scalar length`, l.MaxScalarLength)
+		}
+	}
+}
+
+// size returns the number of nodes that the given node expands to when all aliases that it contains are
+// replaced by the nodes they refer to. An alias to a node that contains the alias counts as one node. The size
+// is capped just above the MaxAliasExpansion limit.
+func (l *limiter) size(n *y3.Node) int {
+	if s, ok := l.sizes[n]; ok {
+		if s < 0 {
+			return 1
+		}
+		return s
+	}
+	l.sizes[n] = -1
+	s := 1
+	if n.Kind == y3.AliasNode {
+		s = l.size(n.Alias)
+	} else {
+		for _, c := range n.Content {
+			if s += l.size(c); s > l.MaxAliasExpansion {
+				s = l.MaxAliasExpansion + 1
+				break
+			}
+		}
+	}
+	l.sizes[n] = s
+	return s
+}
+
+func (l *limiter) exceeded(n *y3.Node, limit string, max int) {
+	panic(yamlError{&LimitError{Limit: limit, Max: max, Position: l.position(n)}})
+}
@@ -0,0 +1,125 @@
+package yaml_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tada/dgo/test/require"
+	"github.com/tada/dgo/vf"
+	"github.com/tada/dgoyaml/yaml"
+)
+
+const laughs = `
+a: &a [lol, lol, lol, lol, lol, lol, lol, lol, lol]
+b: &b [*a, *a, *a, *a, *a, *a, *a, *a, *a]
+c: &c [*b, *b, *b, *b, *b, *b, *b, *b, *b]
+d: &d [*c, *c, *c, *c, *c, *c, *c, *c, *c]
+e: &e [*d, *d, *d, *d, *d, *d, *d, *d, *d]
+f: &f [*e, *e, *e, *e, *e, *e, *e, *e, *e]
+g: &g [*f, *f, *f, *f, *f, *f, *f, *f, *f]
+h: &h [*g, *g, *g, *g, *g, *g, *g, *g, *g]
+i: &i [*h, *h, *h, *h, *h, *h, *h, *h, *h]
+`
+
+func TestUnmarshalWithOptions_maxAliasExpansion(t *testing.T) {
+	_, err := yaml.UnmarshalWithOptions([]byte(laughs), &yaml.DecodeOptions{MaxAliasExpansion: 10000})
+	require.Error(t, `6:8: alias expansion exceeds the maximum of 10000`, err)
+
+	var le *yaml.LimitError
+	require.True(t, errors.As(err, &le))
+	require.Equal(t, `alias expansion`, le.Limit)
+	require.Equal(t, 10000, le.Max)
+	require.Equal(t, yaml.Position{Line: 6, Column: 8}, le.Position)
+
+	v, err := yaml.UnmarshalWithOptions([]byte("a: &a [1, 2]\nb: *a\nc: *a\n"), &yaml.DecodeOptions{MaxAliasExpansion: 6})
+	require.NoError(t, err)
+	require.Equal(t, vf.Map(`a`, vf.Values(1, 2), `b`, vf.Values(1, 2), `c`, vf.Values(1, 2)), v)
+	_, err = yaml.UnmarshalWithOptions([]byte("a: &a [1, 2]\nb: *a\nc: *a\n"), &yaml.DecodeOptions{MaxAliasExpansion: 5})
+	require.Error(t, `3:4: alias expansion exceeds the maximum of 5`, err)
+
+	_, err = yaml.UnmarshalWithOptions([]byte("a: &a [[1, 2, 3], [4, 5, 6]]\nb: *a\n"), &yaml.DecodeOptions{MaxAliasExpansion: 5})
+	require.Error(t, `2:4: alias expansion exceeds the maximum of 5`, err)
+
+	// an alias to an enclosing node counts as one node
+	_, err = yaml.UnmarshalWithOptions([]byte("&a [*a, *a, *a]"), &yaml.DecodeOptions{MaxAliasExpansion: 3})
+	require.NoError(t, err)
+	_, err = yaml.UnmarshalWithOptions([]byte("&a [*a, *a, *a]"), &yaml.DecodeOptions{MaxAliasExpansion: 2})
+	require.Error(t, `1:13: alias expansion exceeds the maximum of 2`, err)
+}
+
+func TestUnmarshalWithOptions_maxDepth(t *testing.T) {
+	opts := &yaml.DecodeOptions{MaxDepth: 2}
+	_, err := yaml.UnmarshalWithOptions([]byte(`a: {b: [1]}`), opts)
+	require.Error(t, `1:8: depth exceeds the maximum of 2`, err)
+
+	v, err := yaml.UnmarshalWithOptions([]byte(`a: {b: 1}`), opts)
+	require.NoError(t, err)
+	require.Equal(t, vf.Map(`a`, vf.Map(`b`, 1)), v)
+
+	_, err = yaml.UnmarshalWithOptions([]byte(strings.Repeat(`[`, 1000)+strings.Repeat(`]`, 1000)), &yaml.DecodeOptions{MaxDepth: 100})
+	require.Error(t, `1:101: depth exceeds the maximum of 100`, err)
+}
+
+func TestUnmarshalWithOptions_maxNodes(t *testing.T) {
+	opts := &yaml.DecodeOptions{MaxNodes: 5}
+	_, err := yaml.UnmarshalWithOptions([]byte(`[1, 2, 3, 4, 5]`), opts)
+	require.Error(t, `1:14: node count exceeds the maximum of 5`, err)
+
+	_, err = yaml.UnmarshalWithOptions([]byte(`[1, 2, 3, 4]`), opts)
+	require.NoError(t, err)
+}
+
+func TestUnmarshalWithOptions_maxScalarLength(t *testing.T) {
+	opts := &yaml.DecodeOptions{MaxScalarLength: 5}
+	_, err := yaml.UnmarshalWithOptions([]byte("a: hello\nb: hello world\n"), opts)
+	require.Error(t, `2:4: scalar length exceeds the maximum of 5`, err)
+
+	_, _, err = yaml.UnmarshalWithPositions(`x.yaml`, []byte("a: hello\nb: hello world\n"), opts)
+	require.Error(t, `x.yaml:2:4: scalar length exceeds the maximum of 5`, err)
+}
+
+func TestDecoder_limits(t *testing.T) {
+	d := yaml.NewDecoder(strings.NewReader("[1, 2]\n---\n[1, 2, 3]\n"))
+	d.SetOptions(&yaml.DecodeOptions{MaxNodes: 3})
+	_, err := d.Decode()
+	require.NoError(t, err)
+	_, err = d.Decode()
+	require.Error(t, `3:8: node count exceeds the maximum of 3`, err)
+}
+
+func TestUnmarshalWithOptions_maxInputSize(t *testing.T) {
+	opts := &yaml.DecodeOptions{MaxInputSize: 8}
+	v, err := yaml.UnmarshalWithOptions([]byte("a: [1]\n"), opts)
+	require.NoError(t, err)
+	require.Equal(t, vf.Map(`a`, vf.Values(1)), v)
+
+	// the size is checked before the input is parsed, so the syntax error is never seen
+	_, err = yaml.UnmarshalWithOptions([]byte("a: [1, 2\n"), opts)
+	require.Equal(t, `input size exceeds the maximum of 8`, err.Error())
+	var le *yaml.LimitError
+	require.True(t, errors.As(err, &le))
+	require.Equal(t, `input size`, le.Limit)
+
+	_, _, err = yaml.UnmarshalWithPositions(`x.yaml`, []byte("a: [1, 2]\n"), opts)
+	require.Equal(t, `x.yaml: input size exceeds the maximum of 8`, err.Error())
+
+	_, err = yaml.UnmarshalDocument([]byte("a: [1, 2]\n"), opts)
+	require.Equal(t, `input size exceeds the maximum of 8`, err.Error())
+}
+
+func TestDecoder_maxInputSize(t *testing.T) {
+	d := yaml.NewDecoder(strings.NewReader("[1, 2]\n---\n[1, 2, 3]\n"))
+	d.SetOptions(&yaml.DecodeOptions{MaxInputSize: 30})
+	_, err := d.Decode()
+	require.NoError(t, err)
+	_, err = d.Decode()
+	require.NoError(t, err)
+
+	d = yaml.NewDecoder(strings.NewReader("[1, 2]\n---\n[1, 2, 3]\n"))
+	d.SetOptions(&yaml.DecodeOptions{MaxInputSize: 10})
+	_, err = d.Decode()
+	require.Equal(t, `input size exceeds the maximum of 10`, err.Error())
+	_, err = d.Decode()
+	require.Equal(t, `input size exceeds the maximum of 10`, err.Error())
+}
@@ -3,66 +3,205 @@ package yaml
 
 import (
 	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/tada/dgo/dgo"
-	"github.com/tada/dgo/vf"
 	y3 "gopkg.in/yaml.v3"
 )
 
+// EncodeOptions controls how dgo.Values are encoded into YAML. The zero value gives the default behavior.
+type EncodeOptions struct {
+	// Anchors causes arrays and maps that occur more than once in the encoded value to be written once
+	// with an anchor and then referenced using aliases. This option is required when encoding values
+	// that contain themselves.
+	Anchors bool
+
+	// Indent is the number of spaces used for each level of indentation. It must be between 2 and 9. The
+	// default is 4.
+	Indent int
+
+	// FlowLength is the maximum number of elements that an array or map may have to be written in flow
+	// style, e.g. [a, b] or {a: 1}. Only arrays and maps that contain nothing but single line scalars are
+	// written in flow style. The default of zero means that flow style is never used.
+	FlowLength int
+
+	// Quote determines how string values are quoted. Keys are never quoted unless required. Multi-line strings
+	// are always written in literal block style.
+	Quote QuoteStyle
+
+	// Canonical causes map entries to be sorted by key and scalars to be written in a normalized form so that
+	// equal values always produce identical output. Floats are written using the shortest representation that
+	// reads back to the same value and times are written in UTC.
+	Canonical bool
+
+	// TrailingZero causes floats that have an integral value to be written with a trailing ".0", e.g. "1.0"
+	// instead of "!!float 1", so that they remain floats when read by parsers that ignore tags. This option is
+	// implied by the Canonical option.
+	TrailingZero bool
+
+	// Spec causes a dgo.StructMapType to be written as a map in the parameter spec layout described by
+	// SpecFromType instead of as a dgo type string.
+	Spec bool
+
+	// YAML11 causes strings that YAML 1.1 parsers would read as something else to be quoted. Such strings are
+	// the booleans of YAML 1.1, e.g. "yes", "off", or "y", and sexagesimal numbers such as "1:20".
+	YAML11 bool
+}
+
 // Marshal decodes the YAML representation of the given bytes into a dgo.Value
 func Marshal(v dgo.Value) (bytes []byte, err error) {
-	defer func() {
-		if r := recover(); r != nil {
-			if ye, ok := r.(yamlError); ok {
-				err = ye.error
-			} else {
-				panic(r)
-			}
-		}
-	}()
-	bytes, err = y3.Marshal(yamlEncodeValue(v))
+	return MarshalWithOptions(v, nil)
+}
+
+// MarshalWithOptions returns the YAML representation of the given value using the given options. A nil
+// options pointer gives the same result as Marshal.
+func MarshalWithOptions(v dgo.Value, opts *EncodeOptions) (bytes []byte, err error) {
+	defer recoverYamlError(&err)
+	e := newEncoder(opts)
+	bytes, err = e.marshal(e.encode(v))
 	return
 }
 
-func yamlEncodeValue(v dgo.Value) (nv *y3.Node) {
+// encoder holds the state of an ongoing encoding of a dgo.Value
+type encoder struct {
+	EncodeOptions
+
+	// refCounts holds the number of references to each array and map instance when the Anchors option is set
+	refCounts map[uintptr]int
+
+	// anchors maps array and map instances that are referenced more than once to their anchored node
+	anchors map[uintptr]*y3.Node
+
+	// path is the path from the root to the value currently being encoded. Each element is either a dgo.Value
+	// map key or an int array index
+	path []interface{}
+}
+
+func newEncoder(opts *EncodeOptions) *encoder {
+	e := &encoder{}
+	if opts != nil {
+		e.EncodeOptions = *opts
+	}
+	return e
+}
+
+// encode returns the *yaml.Node that represents the given value
+func (e *encoder) encode(v dgo.Value) *y3.Node {
+	e.checkStyle()
+	if e.Anchors {
+		e.refCounts = make(map[uintptr]int)
+		e.anchors = make(map[uintptr]*y3.Node)
+		e.countRefs(v)
+	}
+	n := e.yamlEncodeValue(v)
+	if e.styled() {
+		e.style(n, false)
+	}
+	return n
+}
+
+// instanceID returns a value that identifies the instance of the given array or map
+func instanceID(v dgo.Value) uintptr {
+	return reflect.ValueOf(v).Pointer()
+}
+
+// countRefs counts the number of references to each array and map instance found in the given value
+func (e *encoder) countRefs(v dgo.Value) {
+	switch v := v.(type) {
+	case dgo.Array:
+		id := instanceID(v)
+		e.refCounts[id]++
+		if e.refCounts[id] == 1 {
+			v.Each(e.countRefs)
+		}
+	case dgo.Struct:
+		// structs are encoded without anchors
+	case dgo.Map:
+		id := instanceID(v)
+		e.refCounts[id]++
+		if e.refCounts[id] == 1 {
+			v.EachEntry(func(me dgo.MapEntry) {
+				e.countRefs(me.Key())
+				e.countRefs(me.Value())
+			})
+		}
+	}
+}
+
+// anchorOrAlias gives the given node an anchor when the given value is referenced more than once and this is
+// its first occurrence, and returns nil. An alias to the anchored node is returned for subsequent occurrences.
+func (e *encoder) anchorOrAlias(v dgo.Value, n *y3.Node) *y3.Node {
+	if e.refCounts == nil {
+		return nil
+	}
+	id := instanceID(v)
+	if e.refCounts[id] < 2 {
+		return nil
+	}
+	if an, ok := e.anchors[id]; ok {
+		return &y3.Node{Kind: y3.AliasNode, Alias: an, Value: an.Anchor}
+	}
+	n.Anchor = `a` + strconv.Itoa(len(e.anchors)+1)
+	e.anchors[id] = n
+	return nil
+}
+
+func (e *encoder) yamlEncodeValue(v dgo.Value) (nv *y3.Node) {
+	if nv = encodeTagged(v); nv != nil {
+		return
+	}
 	switch v := v.(type) {
 	case dgo.Array:
-		nv = encodeArray(v)
+		nv = e.encodeArray(v)
 	case dgo.Binary:
 		nv = encodeBinary(v)
 	case dgo.Boolean:
 		nv = encodeBoolean(v)
 	case dgo.Float:
-		nv = encodeFloat(v)
+		nv = e.encodeFloat(v)
 	case dgo.Integer:
 		nv = encodeInteger(v)
 	case dgo.Struct:
-		nv = encodeStruct(v)
+		nv = e.encodeStruct(v)
 	case dgo.Map:
-		nv = encodeMap(v)
+		nv = e.encodeMap(v)
 	case dgo.Native:
-		nv = encodeNative(v)
+		nv = e.encodeNative(v)
 	case dgo.Nil:
 		nv = encodeNil()
 	case dgo.String:
 		nv = encodeString(v)
 	case dgo.Time:
-		nv = encodeTime(v)
+		nv = e.encodeTime(v)
 	case dgo.Type:
-		nv = encodeType(v)
+		nv = e.encodeType(v)
+	case *Tagged:
+		if nv = e.encodeCollection(v); nv == nil {
+			nv = e.encodeUnknownTag(v)
+		}
 	default:
-		panic(yamlError{fmt.Errorf(`unable to marshal into value of type %v`, v.Type())})
+		e.fail(``, fmt.Errorf(`unable to marshal into value of type %v`, v.Type()))
 	}
 	return
 }
 
-func encodeArray(v dgo.Array) *y3.Node {
+func (e *encoder) encodeArray(v dgo.Array) *y3.Node {
+	n := &y3.Node{Kind: y3.SequenceNode, Tag: `!!seq`}
+	if an := e.anchorOrAlias(v, n); an != nil {
+		return an
+	}
 	s := make([]*y3.Node, v.Len())
-	v.EachWithIndex(func(e dgo.Value, i int) {
-		s[i] = yamlEncodeValue(e)
+	v.EachWithIndex(func(ev dgo.Value, i int) {
+		e.path = append(e.path, i)
+		s[i] = e.yamlEncodeValue(ev)
+		e.path = e.path[:len(e.path)-1]
 	})
-	return &y3.Node{Kind: y3.SequenceNode, Tag: `!!seq`, Content: s}
+	n.Content = s
+	return n
 }
 
 func encodeBinary(v dgo.Binary) *y3.Node {
@@ -73,8 +212,41 @@ func encodeBoolean(v dgo.Boolean) *y3.Node {
 	return &y3.Node{Kind: y3.ScalarNode, Tag: `!!bool`, Value: v.String()}
 }
 
-func encodeFloat(v dgo.Float) *y3.Node {
-	return &y3.Node{Kind: y3.ScalarNode, Tag: `!!float`, Value: v.String()}
+func (e *encoder) encodeFloat(v dgo.Float) *y3.Node {
+	n := &y3.Node{Kind: y3.ScalarNode, Tag: `!!float`, Value: formatFloat(v, e.TrailingZero || e.Canonical)}
+	if !strings.ContainsAny(n.Value, `.e`) {
+		// An integral value must be explicitly tagged to be read back as a float
+		n.Style = y3.TaggedStyle
+	}
+	return n
+}
+
+// formatFloat returns the shortest string that reads back to the given float. NaN and infinities use the
+// YAML forms .nan, .inf, and -.inf.
+func formatFloat(v dgo.Float, trailingZero bool) string {
+	var s string
+	if bf, ok := v.(dgo.BigFloat); ok {
+		s = bf.GoBigFloat().Text('g', -1)
+	} else {
+		f := v.GoFloat()
+		format := byte('f')
+		if a := math.Abs(f); a != 0 && (a < 1e-6 || a >= 1e21) {
+			format = 'e'
+		}
+		s = strconv.FormatFloat(f, format, -1, 64)
+	}
+	switch s {
+	case `NaN`:
+		return `.nan`
+	case `+Inf`:
+		return `.inf`
+	case `-Inf`:
+		return `-.inf`
+	}
+	if trailingZero && !strings.ContainsAny(s, `.e`) {
+		s += `.0`
+	}
+	return s
 }
 
 func encodeInteger(v dgo.Integer) *y3.Node {
@@ -82,31 +254,24 @@ func encodeInteger(v dgo.Integer) *y3.Node {
 }
 
 // encodeMap returns a *yaml.Node that represents the given map.
-func encodeMap(v dgo.Map) *y3.Node {
-	s := make([]*y3.Node, v.Len()*2)
-	i := 0
-	v.EachEntry(func(e dgo.MapEntry) {
-		s[i] = yamlEncodeValue(e.Key())
-		i++
-		s[i] = yamlEncodeValue(e.Value())
-		i++
-	})
-	return &y3.Node{Kind: y3.MappingNode, Tag: `!!map`, Content: s}
+func (e *encoder) encodeMap(v dgo.Map) *y3.Node {
+	n := &y3.Node{Kind: y3.MappingNode, Tag: `!!map`}
+	if an := e.anchorOrAlias(v, n); an != nil {
+		return an
+	}
+	s := make([]*y3.Node, 0, v.Len()*2)
+	for _, me := range e.entries(v) {
+		k := me.Key()
+		e.path = append(e.path, k)
+		s = append(s, e.yamlEncodeValue(k), e.yamlEncodeValue(me.Value()))
+		e.path = e.path[:len(e.path)-1]
+	}
+	n.Content = s
+	return n
 }
 
-func encodeNative(n dgo.Native) *y3.Node {
-	iv := n.GoValue()
-	if ym, ok := iv.(y3.Marshaler); ok {
-		yv, err := ym.MarshalYAML()
-		if err != nil {
-			panic(yamlError{err})
-		}
-		if n, ok := yv.(*y3.Node); ok {
-			return n
-		}
-		return yamlEncodeValue(vf.Value(yv))
-	}
-	panic(yamlError{fmt.Errorf(`unable to marshal into value of type %T`, iv)})
+func (e *encoder) encodeNative(n dgo.Native) *y3.Node {
+	return e.encodeGo(reflect.ValueOf(n.GoValue()))
 }
 
 func encodeNil() *y3.Node {
@@ -119,29 +284,30 @@ func encodeString(v dgo.String) *y3.Node {
 	return n
 }
 
-func encodeStruct(v dgo.Struct) *y3.Node {
-	// A bit wasteful but this is currently the only way to create a yaml.Node
-	// from a struct
-	n := &y3.Node{}
-	b, err := y3.Marshal(v.GoStruct())
-	if err == nil {
-		err = y3.Unmarshal(b, n)
-	}
-	if err != nil {
-		panic(yamlError{err})
-	}
-	// n is the document node at this point.
-	return n.Content[0]
+// encodeStruct returns a mapping node that represents the Go struct of the given value
+func (e *encoder) encodeStruct(v dgo.Struct) *y3.Node {
+	return e.encodeGo(reflect.ValueOf(v.GoStruct()))
 }
 
-func encodeTime(v dgo.Time) *y3.Node {
+func (e *encoder) encodeTime(v dgo.Time) *y3.Node {
+	t := *v.GoTime()
+	if e.Canonical {
+		t = t.UTC()
+	}
 	return &y3.Node{
 		Kind:  y3.ScalarNode,
 		Tag:   `!!timestamp`,
-		Value: v.GoTime().Format(time.RFC3339Nano),
+		Value: t.Format(time.RFC3339Nano),
 		Style: y3.TaggedStyle}
 }
 
-func encodeType(t dgo.Type) *y3.Node {
+func (e *encoder) encodeType(t dgo.Type) *y3.Node {
+	if st, ok := t.(dgo.StructMapType); ok && e.Spec {
+		spec, err := SpecFromType(st, nil)
+		if err != nil {
+			e.fail(``, err)
+		}
+		return e.yamlEncodeValue(spec)
+	}
 	return &y3.Node{Tag: `!puppet.com,2019:dgo/type`, Kind: y3.ScalarNode, Value: t.String()}
 }
@@ -2,6 +2,9 @@ package yaml_test
 
 import (
 	"errors"
+	"fmt"
+	"math"
+	"math/big"
 	"testing"
 	"time"
 
@@ -79,7 +82,55 @@ b: 32
 	m = vf.Map(&sFail)
 	_, err = yaml.Marshal(m)
 	require.NotNil(t, err)
-	require.Equal(t, `errFailing`, err.Error())
+	require.Equal(t, `b: errFailing`, err.Error())
+}
+
+func TestMarshal_structMap_embedded(t *testing.T) {
+	type A struct {
+		Note string `yaml:"note"`
+	}
+
+	type B struct {
+		A
+		Note string `yaml:"note"`
+	}
+
+	type C struct {
+		A    `yaml:",inline"`
+		Note string `yaml:"note"`
+	}
+
+	j, err := yaml.Marshal(vf.Map(&B{A: A{Note: `inner`}, Note: `outer`}))
+	require.NoError(t, err)
+	require.Equal(t, `a:
+    note: inner
+note: outer
+`, string(j))
+
+	_, err = yaml.Marshal(vf.Map(&C{A: A{Note: `inner`}, Note: `outer`}))
+	require.Equal(t, `duplicated key "note" in struct yaml_test.C`, err.Error())
+}
+
+func TestMarshal_structMap_inlineMap(t *testing.T) {
+	type structInline struct {
+		A     string                 `yaml:"a"`
+		Extra map[string]interface{} `yaml:",inline"`
+		None  map[string]int         `yaml:",inline"`
+	}
+
+	s := structInline{A: `Alpha`, Extra: map[string]interface{}{`z`: 1, `b`: []int{2}, `c`: `x`}}
+	j, err := yaml.Marshal(vf.Map(&s))
+	require.NoError(t, err)
+	require.Equal(t, `a: Alpha
+b:
+  - 2
+c: x
+z: 1
+`, string(j))
+
+	s.Extra = map[string]interface{}{`f`: &marshalTestFail{}}
+	_, err = yaml.Marshal(vf.Map(&s))
+	require.Equal(t, `f: errFailing`, err.Error())
 }
 
 func TestMarshal_timestamp(t *testing.T) {
@@ -103,6 +154,7 @@ var errFailing = errors.New("errFailing")
 
 type testNoMarshaler struct {
 	A string
+	C chan int
 }
 
 type testMarshaler struct {
@@ -171,14 +223,15 @@ func TestMarshal_obscure(t *testing.T) {
 	m := vf.MutableValues(obscureValue(0))
 	_, err := yaml.Marshal(m)
 	require.NotNil(t, err)
-	require.Equal(t, `unable to marshal into value of type yaml_test.obscureValue`, err.Error())
+	require.Equal(t, `[0]: unable to marshal into value of type yaml_test.obscureValue`, err.Error())
 }
 
 func TestMarshal_fail(t *testing.T) {
 	m := vf.MutableValues(&marshalTestFail{})
 	_, err := yaml.Marshal(m)
 	require.NotNil(t, err)
-	require.Equal(t, `errFailing`, err.Error())
+	require.Equal(t, `[0]: errFailing`, err.Error())
+	require.True(t, errors.Is(err, errFailing))
 }
 
 func TestMarshal_panic(t *testing.T) {
@@ -187,8 +240,189 @@ func TestMarshal_panic(t *testing.T) {
 }
 
 func TestMarshal_failNoMarshaler(t *testing.T) {
-	m := vf.MutableValues(&testNoMarshaler{})
+	m := vf.MutableValues(&testNoMarshaler{C: make(chan int)})
 	_, err := yaml.Marshal(m)
 	require.NotNil(t, err)
-	require.Equal(t, `unable to marshal into value of type *yaml_test.testNoMarshaler`, err.Error())
+	require.Equal(t, `[0].c: unable to marshal into value of type chan int`, err.Error())
+}
+
+func ExampleMarshalWithOptions_style() {
+	v := vf.Map(
+		`name`, `example`,
+		`ports`, vf.Values(80, 443),
+		`labels`, vf.Map(`tier`, `web`),
+		`script`, "echo hello\necho world\n",
+		`hosts`, vf.Values(vf.Map(`name`, `a`, `ip`, `10.0.0.1`)))
+	b, err := yaml.MarshalWithOptions(v, &yaml.EncodeOptions{Indent: 2, FlowLength: 4, Quote: yaml.QuoteSingle})
+	if err == nil {
+		fmt.Print(string(b))
+	}
+	// Output:
+	// name: 'example'
+	// ports: [80, 443]
+	// labels: {tier: 'web'}
+	// script: |
+	//   echo hello
+	//   echo world
+	// hosts:
+	// - {name: 'a', ip: '10.0.0.1'}
+}
+
+func TestMarshalWithOptions_indent(t *testing.T) {
+	v := vf.Map(`a`, vf.Map(`b`, vf.Map(`c`, 1)), `d`, vf.Values(vf.Map(`e`, 2, `f`, 3)))
+	b, err := yaml.MarshalWithOptions(v, &yaml.EncodeOptions{Indent: 2})
+	require.NoError(t, err)
+	require.Equal(t, `a:
+  b:
+    c: 1
+d:
+- e: 2
+  f: 3
+`, string(b))
+
+	_, err = yaml.MarshalWithOptions(v, &yaml.EncodeOptions{Indent: 1})
+	require.Error(t, `indent must be between 2 and 9, got 1`, err)
+	_, err = yaml.MarshalWithOptions(v, &yaml.EncodeOptions{Indent: 10})
+	require.Error(t, `indent must be between 2 and 9, got 10`, err)
+}
+
+func TestMarshalWithOptions_flowLength(t *testing.T) {
+	v := vf.Map(
+		`short`, vf.Values(1, 2),
+		`long`, vf.Values(1, 2, 3),
+		`nested`, vf.Values(vf.Values(1)),
+		`multiline`, vf.Values("a\nb"),
+		`empty`, vf.Values())
+	b, err := yaml.MarshalWithOptions(v, &yaml.EncodeOptions{FlowLength: 2})
+	require.NoError(t, err)
+	require.Equal(t, `short: [1, 2]
+long:
+  - 1
+  - 2
+  - 3
+nested:
+  - [1]
+multiline:
+  - |-
+    a
+    b
+empty: []
+`, string(b))
+}
+
+func TestMarshalWithOptions_quote(t *testing.T) {
+	v := vf.Map(`a`, `plain`, `b`, `it's`, `c`, "two\nlines", `d`, 1, `true`, `true`)
+	b, err := yaml.MarshalWithOptions(v, &yaml.EncodeOptions{Quote: yaml.QuoteDouble})
+	require.NoError(t, err)
+	require.Equal(t, `a: "plain"
+b: "it's"
+c: |-
+    two
+    lines
+d: 1
+"true": "true"
+`, string(b))
+
+	b, err = yaml.MarshalWithOptions(v, &yaml.EncodeOptions{Quote: yaml.QuoteSingle})
+	require.NoError(t, err)
+	require.Equal(t, `a: 'plain'
+b: 'it''s'
+c: |-
+    two
+    lines
+d: 1
+"true": 'true'
+`, string(b))
+
+	_, err = yaml.MarshalWithOptions(v, &yaml.EncodeOptions{Quote: yaml.QuoteStyle(3)})
+	require.Error(t, `invalid quote style 3`, err)
+}
+
+type benchmarkStruct struct {
+	Name    string            `json:"name"`
+	Port    int               `yaml:"port"`
+	Enabled bool              `yaml:"enabled"`
+	Tags    []string          `yaml:"tags"`
+	Labels  map[string]string `yaml:"labels,omitempty"`
+}
+
+func benchmarkStructs() dgo.Array {
+	s := make([]dgo.Value, 1000)
+	for i := range s {
+		s[i] = vf.Map(&benchmarkStruct{
+			Name:    `service`,
+			Port:    i,
+			Enabled: i%2 == 0,
+			Tags:    []string{`a`, `b`},
+			Labels:  map[string]string{`tier`: `web`}})
+	}
+	return vf.WrapSlice(s)
+}
+
+func BenchmarkMarshal_structs(b *testing.B) {
+	v := benchmarkStructs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := yaml.Marshal(v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkMarshal_goStructs gives a baseline for BenchmarkMarshal_structs by marshaling the Go structs directly
+func BenchmarkMarshal_goStructs(b *testing.B) {
+	v := make([]interface{}, 0, 1000)
+	benchmarkStructs().Each(func(e dgo.Value) { v = append(v, e.(dgo.Struct).GoStruct()) })
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := y3.Marshal(v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestMarshal_specialFloats(t *testing.T) {
+	v := vf.Values(math.NaN(), math.Inf(1), math.Inf(-1), vf.BigFloat(new(big.Float).SetInf(false)))
+	b, err := yaml.Marshal(v)
+	require.NoError(t, err)
+	require.Equal(t, "- .nan\n- .inf\n- -.inf\n- .inf\n", string(b))
+
+	rv, err := yaml.Unmarshal(b)
+	require.NoError(t, err)
+	a := rv.(dgo.Array)
+	require.True(t, math.IsNaN(a.Get(0).(dgo.Float).GoFloat()))
+	require.Equal(t, vf.Values(math.Inf(1), math.Inf(-1), math.Inf(1)), a.Slice(1, 4))
+}
+
+func TestMarshal_floatRoundTrip(t *testing.T) {
+	f := 0.1
+	fs := []float64{f + 0.2, 1.0 / 3, 1e21, 1e20, 1e-6, 1e-7, -2.5e-300, math.MaxFloat64, math.SmallestNonzeroFloat64, 123456789}
+	v := vf.Array(fs)
+	b, err := yaml.Marshal(v)
+	require.NoError(t, err)
+	require.Equal(t, `- 0.30000000000000004
+- 0.3333333333333333
+- 1e+21
+- !!float 100000000000000000000
+- 0.000001
+- 1e-07
+- -2.5e-300
+- 1.7976931348623157e+308
+- 5e-324
+- !!float 123456789
+`, string(b))
+	rv, err := yaml.Unmarshal(b)
+	require.NoError(t, err)
+	require.Equal(t, v, rv)
+}
+
+func TestMarshalWithOptions_trailingZero(t *testing.T) {
+	v := vf.Values(1.0, -3.0, 2.5, 1e21, vf.BigFloat(big.NewFloat(4)))
+	b, err := yaml.MarshalWithOptions(v, &yaml.EncodeOptions{TrailingZero: true})
+	require.NoError(t, err)
+	require.Equal(t, "- 1.0\n- -3.0\n- 2.5\n- 1e+21\n- 4.0\n", string(b))
+
+	b, err = yaml.Marshal(v)
+	require.NoError(t, err)
+	require.Equal(t, "- !!float 1\n- !!float -3\n- 2.5\n- 1e+21\n- !!float 4\n", string(b))
 }
@@ -0,0 +1,76 @@
+package yaml_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tada/dgo/dgo"
+	"github.com/tada/dgo/test/require"
+	"github.com/tada/dgo/vf"
+	"github.com/tada/dgoyaml/yaml"
+)
+
+func ExampleUnmarshal_merge() {
+	v, err := yaml.Unmarshal([]byte(`
+defaults: &defaults
+  adapter: postgres
+  host: localhost
+development:
+  <<: *defaults
+  database: dev
+test:
+  <<: *defaults
+  host: test.example.com
+`))
+	if err == nil {
+		m := v.(dgo.Map)
+		fmt.Println(m.Get(`development`))
+		fmt.Println(m.Get(`test`))
+	}
+	// Output:
+	// map[adapter:postgres host:localhost database:dev]
+	// map[adapter:postgres host:test.example.com]
+}
+
+func TestUnmarshal_merge(t *testing.T) {
+	v, err := yaml.Unmarshal([]byte(`
+a: &a {x: 1, y: 2}
+b: &b {y: 3, z: 4}
+single:
+  w: 0
+  <<: *a
+  x: 5
+multiple:
+  <<: [*a, *b]
+  v: 6
+inline:
+  <<: {x: 7}
+empty:
+  <<: []
+quoted:
+  "<<": not a merge
+`))
+	require.NoError(t, err)
+	m := v.(dgo.Map)
+	require.Equal(t, `map[w:0 y:2 x:5]`, fmt.Sprint(m.Get(`single`)))
+	require.Equal(t, `map[x:1 y:2 z:4 v:6]`, fmt.Sprint(m.Get(`multiple`)))
+	require.Equal(t, vf.Map(`x`, 7), m.Get(`inline`))
+	require.Equal(t, vf.Map(), m.Get(`empty`))
+	require.Equal(t, vf.Map(`<<`, `not a merge`), m.Get(`quoted`))
+
+	// merged values share identity with the values of the merged map
+	v, err = yaml.Unmarshal([]byte(`
+a: &a {x: [1]}
+b: {<<: *a}
+`))
+	require.NoError(t, err)
+	m = v.(dgo.Map)
+	require.Same(t, m.Get(`a`).(dgo.Map).Get(`x`), m.Get(`b`).(dgo.Map).Get(`x`))
+}
+
+func TestUnmarshal_merge_bad(t *testing.T) {
+	_, err := yaml.Unmarshal([]byte("a: {<<: 1}\n"))
+	require.Error(t, `map merge requires a map or a sequence of maps as the value`, err)
+	_, err = yaml.Unmarshal([]byte("a: {<<: [{x: 1}, 2]}\n"))
+	require.Error(t, `map merge requires a map or a sequence of maps as the value`, err)
+}
@@ -0,0 +1,130 @@
+package yaml
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tada/dgo/dgo"
+	"github.com/tada/dgo/vf"
+	y3 "gopkg.in/yaml.v3"
+)
+
+// Position is the location of a value in a YAML source
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// String returns the position in the form file:line:column, or line:column when the position has no file. The
+// column is omitted when it is unknown, i.e. zero.
+func (p Position) String() string {
+	lc := strconv.Itoa(p.Line)
+	if p.Column > 0 {
+		lc += `:` + strconv.Itoa(p.Column)
+	}
+	if p.File == `` {
+		return lc
+	}
+	return p.File + `:` + lc
+}
+
+// Positions maps the path of each value in a decoded document to the position of that value in the YAML
+// source. The position of the key is also recorded for values that are contained in a map.
+//
+// A path is formed by joining map keys with a dot and by appending array indexes in brackets, e.g. the
+// path "c[3].name" denotes the value of the "name" key in the fourth element of the array found under the
+// key "c". The path of the document root is the empty string.
+//
+// A string key is written as is when it consists of ASCII letters, digits, underscores, and dashes, starts
+// with a letter or an underscore, and isn't one of "nil", "true", or "false". Other string keys are written as
+// double quoted Go strings, so the key "a.b" gives the path `"a.b"` which differs from the path "a.b" of the
+// key "b" under the key "a". Keys that aren't strings are written using their dgo string form, so the integer
+// key 8080 gives the path "8080" and the string key "8080" gives the path `"8080"`. The Path function returns
+// the path for a given sequence of keys and indexes.
+type Positions struct {
+	file   string
+	values map[string]Position
+	keys   map[string]Position
+}
+
+func newPositions(file string) *Positions {
+	return &Positions{file: file, values: make(map[string]Position), keys: make(map[string]Position)}
+}
+
+// Value returns the position of the value at the given path and true, or an empty position and false if
+// no such value exists.
+func (p *Positions) Value(path string) (Position, bool) {
+	pos, ok := p.values[path]
+	return pos, ok
+}
+
+// Key returns the position of the map key for the value at the given path and true, or an empty position
+// and false if no such key exists.
+func (p *Positions) Key(path string) (Position, bool) {
+	pos, ok := p.keys[path]
+	return pos, ok
+}
+
+func (p *Positions) addValue(path []interface{}, n *y3.Node) {
+	p.values[pathString(path)] = p.position(n)
+}
+
+func (p *Positions) addKey(path []interface{}, n *y3.Node) {
+	p.keys[pathString(path)] = p.position(n)
+}
+
+func (p *Positions) position(n *y3.Node) Position {
+	return Position{File: p.file, Line: n.Line, Column: n.Column}
+}
+
+// Path returns the path formed by the given elements, where each element is an int array index or a map key.
+// Map keys that aren't dgo.Values are converted using vf.Value, so an integer map key must be given as a
+// dgo.Integer. See Positions for a description of paths.
+func Path(elements ...interface{}) string {
+	path := make([]interface{}, len(elements))
+	for i, pe := range elements {
+		if _, ok := pe.(int); !ok {
+			pe = vf.Value(pe)
+		}
+		path[i] = pe
+	}
+	return pathString(path)
+}
+
+// bareKeyPattern matches the string keys that are written without quotes in a path
+var bareKeyPattern = regexp.MustCompile(`\A[A-Za-z_][A-Za-z0-9_-]*\z`)
+
+// pathKey returns the form of the given string key in a path
+func pathKey(s string) string {
+	if bareKeyPattern.MatchString(s) && s != `nil` && s != `true` && s != `false` {
+		return s
+	}
+	return strconv.Quote(s)
+}
+
+// pathString returns the string form of a path where each element is either a dgo.Value map key or an
+// int array index.
+func pathString(path []interface{}) string {
+	sb := strings.Builder{}
+	for _, pe := range path {
+		switch pe := pe.(type) {
+		case int:
+			sb.WriteByte('[')
+			sb.WriteString(strconv.Itoa(pe))
+			sb.WriteByte(']')
+		case dgo.String:
+			if sb.Len() > 0 {
+				sb.WriteByte('.')
+			}
+			sb.WriteString(pathKey(pe.GoString()))
+		case dgo.Value:
+			if sb.Len() > 0 {
+				sb.WriteByte('.')
+			}
+			sb.WriteString(pe.String())
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,109 @@
+package yaml_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tada/dgo/test/require"
+	"github.com/tada/dgo/vf"
+	"github.com/tada/dgoyaml/yaml"
+)
+
+func ExampleUnmarshalWithPositions() {
+	_, ps, err := yaml.UnmarshalWithPositions(`params.yaml`, []byte(`
+host: example.com
+ports:
+  - 22
+  - 80
+`), nil)
+	if err == nil {
+		pos, _ := ps.Value(`ports[1]`)
+		fmt.Println(pos)
+		pos, _ = ps.Key(`ports`)
+		fmt.Println(pos)
+	}
+	// Output:
+	// params.yaml:5:5
+	// params.yaml:3:1
+}
+
+func TestUnmarshalWithPositions(t *testing.T) {
+	v, ps, err := yaml.UnmarshalWithPositions(`test.yaml`, []byte(`a: 1
+b:
+  c: [x, {d: true}]
+  4: four
+`), nil)
+	require.NoError(t, err)
+	require.Equal(t, vf.Map(`a`, 1, `b`, vf.Map(`c`, vf.Values(`x`, vf.Map(`d`, true)), 4, `four`)), v)
+
+	pos, ok := ps.Value(``)
+	require.True(t, ok)
+	require.Equal(t, yaml.Position{File: `test.yaml`, Line: 1, Column: 1}, pos)
+
+	pos, ok = ps.Value(`b.c[1].d`)
+	require.True(t, ok)
+	require.Equal(t, `test.yaml:3:14`, pos.String())
+
+	pos, ok = ps.Key(`b.c[1].d`)
+	require.True(t, ok)
+	require.Equal(t, `test.yaml:3:11`, pos.String())
+
+	pos, ok = ps.Value(`b.4`)
+	require.True(t, ok)
+	require.Equal(t, `test.yaml:4:6`, pos.String())
+
+	_, ok = ps.Key(`b.c[1]`)
+	require.False(t, ok)
+
+	_, ok = ps.Value(`b.c[2]`)
+	require.False(t, ok)
+}
+
+func TestUnmarshalWithPositions_quotedKeys(t *testing.T) {
+	_, ps, err := yaml.UnmarshalWithPositions(`test.yaml`, []byte(`a.b: 1
+a:
+  b: 2
+8080: int
+"8080": string
+true: bool
+"true": string
+"": empty
+`), nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		path string
+		pos  string
+	}{
+		{`"a.b"`, `test.yaml:1:6`},
+		{`a.b`, `test.yaml:3:6`},
+		{`8080`, `test.yaml:4:7`},
+		{`"8080"`, `test.yaml:5:9`},
+		{`true`, `test.yaml:6:7`},
+		{`"true"`, `test.yaml:7:9`},
+		{`""`, `test.yaml:8:5`},
+	}
+	for _, tt := range tests {
+		pos, ok := ps.Value(tt.path)
+		require.True(t, ok)
+		require.Equal(t, tt.pos, pos.String())
+	}
+}
+
+func TestPath(t *testing.T) {
+	require.Equal(t, `c[3].name`, yaml.Path(`c`, 3, `name`))
+	require.Equal(t, `metadata.labels."app.kubernetes.io/name"`, yaml.Path(`metadata`, `labels`, `app.kubernetes.io/name`))
+	require.Equal(t, `a."[0]"[0].8080."8080".nil."nil"._x-1`, yaml.Path(`a`, `[0]`, 0, vf.Integer(8080), `8080`, nil, `nil`, `_x-1`))
+	require.Equal(t, `"a\nb"`, yaml.Path("a\nb"))
+	require.Equal(t, ``, yaml.Path())
+}
+
+func TestUnmarshalWithPositions_bad_yaml(t *testing.T) {
+	_, _, err := yaml.UnmarshalWithPositions(`test.yaml`, []byte(": :\n"), nil)
+	require.Error(t, `did not find expected key`, err)
+}
+
+func TestUnmarshalWithPositions_error(t *testing.T) {
+	_, _, err := yaml.UnmarshalWithPositions(`test.yaml`, []byte("t: !!timestamp 2019-13-06T07:15:00-07:00\n"), nil)
+	require.Error(t, `cannot decode`, err)
+}
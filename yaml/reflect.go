@@ -0,0 +1,227 @@
+package yaml
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tada/dgo/dgo"
+	"github.com/tada/dgo/vf"
+	y3 "gopkg.in/yaml.v3"
+)
+
+// encodeGo returns a *yaml.Node that represents the given Go value. Values that implement yaml.Marshaler,
+// encoding.TextMarshaler, or json.Marshaler are encoded using the first of those interfaces that they implement,
+// except for times and big numbers which retain their YAML representation. Other values are encoded using
+// reflection.
+func (e *encoder) encodeGo(rv reflect.Value) *y3.Node {
+	if !rv.IsValid() || (rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface) && rv.IsNil() {
+		return encodeNil()
+	}
+	iv := rv.Interface()
+	switch iv.(type) {
+	case time.Time, *time.Time, *big.Int, *big.Float:
+		return e.yamlEncodeValue(vf.Value(iv))
+	}
+	if n := e.encodeMarshaler(iv); n != nil {
+		return n
+	}
+	if rv.Kind() != reflect.Ptr && rv.CanAddr() {
+		if n := e.encodeMarshaler(rv.Addr().Interface()); n != nil {
+			return n
+		}
+	}
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return e.encodeGo(rv.Elem())
+	case reflect.Struct:
+		return e.encodeGoStruct(rv)
+	case reflect.Array:
+		s := make([]*y3.Node, rv.Len())
+		for i := range s {
+			e.path = append(e.path, i)
+			s[i] = e.encodeGo(rv.Index(i))
+			e.path = e.path[:len(e.path)-1]
+		}
+		return &y3.Node{Kind: y3.SequenceNode, Tag: `!!seq`, Content: s}
+	}
+	v := vf.Value(iv)
+	if _, ok := v.(dgo.Native); ok {
+		e.fail(``, fmt.Errorf(`unable to marshal into value of type %s`, rv.Type()))
+	}
+	return e.yamlEncodeValue(v)
+}
+
+// encodeMarshaler returns the *yaml.Node produced by the marshaler interface that the given value implements, or
+// nil if it implements none of them.
+func (e *encoder) encodeMarshaler(iv interface{}) *y3.Node {
+	switch m := iv.(type) {
+	case y3.Marshaler:
+		yv, err := m.MarshalYAML()
+		if err != nil {
+			e.fail(``, err)
+		}
+		if n, ok := yv.(*y3.Node); ok {
+			return n
+		}
+		return e.yamlEncodeValue(vf.Value(yv))
+	case encoding.TextMarshaler:
+		b, err := m.MarshalText()
+		if err != nil {
+			e.fail(``, err)
+		}
+		return encodeString(vf.String(string(b)))
+	case json.Marshaler:
+		b, err := m.MarshalJSON()
+		if err != nil {
+			e.fail(``, err)
+		}
+		v, err := Unmarshal(b)
+		if err != nil {
+			e.fail(``, err)
+		}
+		return e.yamlEncodeValue(v)
+	}
+	return nil
+}
+
+// encodeGoStruct returns a mapping node with one entry for each exported field of the given struct
+func (e *encoder) encodeGoStruct(rv reflect.Value) *y3.Node {
+	n := &y3.Node{Kind: y3.MappingNode, Tag: `!!map`}
+	e.addGoFields(n, rv, make(map[string]bool))
+	return n
+}
+
+// addGoFields adds the exported fields of the given struct to the given mapping node. The name of a field is taken
+// from its "yaml" tag, or its "json" tag when it has no "yaml" tag, and defaults to the lower case field name. The
+// tag options "omitempty", "flow", and "inline" are honored, where "inline" applies to struct and map fields.
+// Embedded structs are only inlined when they have the "inline" option, as with yaml.v3.
+//
+// The given set holds the keys added so far. A field with a key that is already present is an error. Only the keys
+// are collected when the node is nil, which is the case for the fields of an inlined nil struct pointer.
+func (e *encoder) addGoFields(n *y3.Node, rv reflect.Value, keys map[string]bool) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		name, opts, skip := fieldTag(f)
+		if f.PkgPath != `` || skip {
+			continue
+		}
+		fv := rv.Field(i)
+		if hasOption(opts, `inline`) {
+			e.addGoInline(n, fv, keys)
+			continue
+		}
+		if name == `` {
+			name = strings.ToLower(f.Name)
+		}
+		if keys[name] {
+			e.fail(``, fmt.Errorf(`duplicated key %q in struct %s`, name, rt))
+		}
+		keys[name] = true
+		if n == nil || hasOption(opts, `omitempty`) && isEmptyValue(fv) {
+			continue
+		}
+		e.path = append(e.path, vf.String(name))
+		vn := e.encodeGo(fv)
+		e.path = e.path[:len(e.path)-1]
+		if hasOption(opts, `flow`) {
+			vn.Style |= y3.FlowStyle
+		}
+		n.Content = append(n.Content, encodeString(vf.String(name)), vn)
+	}
+}
+
+// addGoInline adds the fields of the given struct, or the entries of the given map, to the given mapping node
+func (e *encoder) addGoInline(n *y3.Node, fv reflect.Value, keys map[string]bool) {
+	switch {
+	case isStruct(fv):
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				// the keys of a nil struct are reserved even though its fields are not written
+				n = nil
+				fv = reflect.Zero(fv.Type().Elem())
+			} else {
+				fv = fv.Elem()
+			}
+		}
+		e.addGoFields(n, fv, keys)
+	case fv.Kind() == reflect.Map:
+		if n != nil {
+			e.addGoMapEntries(n, fv, keys)
+		}
+	default:
+		e.fail(``, fmt.Errorf(`option ,inline needs a struct value or map field, got %s`, fv.Type()))
+	}
+}
+
+// addGoMapEntries adds the entries of the given map to the given mapping node, sorted by key. A key that is equal
+// to the key of a struct field is an error.
+func (e *encoder) addGoMapEntries(n *y3.Node, rv reflect.Value, keys map[string]bool) {
+	mks := rv.MapKeys()
+	sort.Slice(mks, func(i, j int) bool {
+		return compareKeys(vf.Value(mks[i].Interface()), vf.Value(mks[j].Interface())) < 0
+	})
+	for _, k := range mks {
+		kn := e.encodeGo(k)
+		if kn.ShortTag() == `!!str` && keys[kn.Value] {
+			e.fail(``, fmt.Errorf(`cannot have key %q in inlined map: conflicts with struct field`, kn.Value))
+		}
+		if kn.ShortTag() == `!!str` {
+			keys[kn.Value] = true
+		}
+		e.path = append(e.path, vf.Value(k.Interface()))
+		vn := e.encodeGo(rv.MapIndex(k))
+		e.path = e.path[:len(e.path)-1]
+		n.Content = append(n.Content, kn, vn)
+	}
+}
+
+// fieldTag returns the name and the options of the "yaml" tag of the given field, or of its "json" tag when it
+// has no "yaml" tag, and true if the tag is "-".
+func fieldTag(f reflect.StructField) (string, string, bool) {
+	tag, ok := f.Tag.Lookup(`yaml`)
+	if !ok {
+		tag = f.Tag.Get(`json`)
+	}
+	if tag == `-` {
+		return ``, ``, true
+	}
+	if i := strings.IndexByte(tag, ','); i >= 0 {
+		return tag[:i], tag[i+1:], false
+	}
+	return tag, ``, false
+}
+
+// hasOption returns true if the given comma separated tag options contains the given option
+func hasOption(opts, opt string) bool {
+	for _, o := range strings.Split(opts, `,`) {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// isStruct returns true if the given value is a struct or a pointer to a struct
+func isStruct(rv reflect.Value) bool {
+	t := rv.Type()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+// isEmptyValue returns true if a field with the given value is omitted by the "omitempty" option
+func isEmptyValue(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	}
+	return rv.IsZero()
+}
@@ -0,0 +1,194 @@
+package yaml_test
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/tada/dgo/test/require"
+	"github.com/tada/dgo/vf"
+	"github.com/tada/dgoyaml/yaml"
+)
+
+type reflectAddress struct {
+	Street string `json:"street"`
+	Zip    string `yaml:"zip,omitempty"`
+}
+
+type reflectPerson struct {
+	Name      string            `yaml:"name"`
+	Age       int               `json:"age,omitempty"`
+	Addresses []reflectAddress  `json:"addresses"`
+	Tags      []string          `yaml:"tags,flow"`
+	Labels    map[string]string `yaml:"labels,omitempty"`
+	Secret    string            `yaml:"-"`
+	Nickname  *string
+	private   int
+}
+
+type reflectLevel int
+
+func (l reflectLevel) MarshalText() ([]byte, error) {
+	if l < 0 {
+		return nil, errors.New(`negative level`)
+	}
+	return []byte(fmt.Sprintf(`level-%d`, l)), nil
+}
+
+type reflectJSON struct {
+	v string
+}
+
+func (j reflectJSON) MarshalJSON() ([]byte, error) {
+	if j.v == `` {
+		return nil, errors.New(`empty json`)
+	}
+	return []byte(j.v), nil
+}
+
+type ReflectBase struct {
+	ID int `yaml:"id"`
+}
+
+type ReflectExtra struct {
+	Note string `yaml:"note"`
+}
+
+type reflectEmbedding struct {
+	ReflectBase
+	*ReflectExtra `yaml:",inline"`
+	Level         reflectLevel `yaml:"level"`
+	Dash          int          `yaml:"-,"`
+	Points        [2]int       `yaml:"points"`
+}
+
+type reflectShadow struct {
+	*ReflectExtra `yaml:",inline"`
+	Note          string `yaml:"note"`
+}
+
+type reflectMapShadow struct {
+	More map[string]int `yaml:",inline"`
+	Note string         `yaml:"note"`
+}
+
+type reflectPointerMarshaler struct {
+	A testMarshaler `yaml:"a"`
+}
+
+func ExampleMarshal_reflection() {
+	p := &reflectPerson{
+		Name:      `Alice`,
+		Age:       42,
+		Addresses: []reflectAddress{{Street: `Main Street 1`}},
+		Tags:      []string{`a`, `b`},
+		Secret:    `xyz`}
+	b, err := yaml.Marshal(vf.Value(p))
+	if err == nil {
+		fmt.Print(string(b))
+	}
+	// Output:
+	// name: Alice
+	// age: 42
+	// addresses:
+	//   - street: Main Street 1
+	// tags: [a, b]
+	// nickname: null
+}
+
+func TestMarshal_reflection_embedded(t *testing.T) {
+	v := vf.Value(reflectEmbedding{
+		ReflectBase: ReflectBase{ID: 3},
+		Level:       2,
+		Dash:        7,
+		Points:      [2]int{1, 2}})
+	b, err := yaml.Marshal(v)
+	require.NoError(t, err)
+	require.Equal(t, `reflectbase:
+    id: 3
+level: level-2
+'-': 7
+points:
+  - 1
+  - 2
+`, string(b))
+
+	v = vf.Value(reflectEmbedding{ReflectExtra: &ReflectExtra{Note: `pointer`}})
+	b, err = yaml.Marshal(v)
+	require.NoError(t, err)
+	require.Equal(t, `reflectbase:
+    id: 0
+note: pointer
+level: level-0
+'-': 0
+points:
+  - 0
+  - 0
+`, string(b))
+}
+
+func TestMarshal_reflection_duplicateKey(t *testing.T) {
+	_, err := yaml.Marshal(vf.Value(reflectShadow{Note: `outer`}))
+	require.Equal(t, `duplicated key "note" in struct yaml_test.reflectShadow`, err.Error())
+
+	_, err = yaml.Marshal(vf.Map(`a`, vf.Value(reflectShadow{ReflectExtra: &ReflectExtra{Note: `inner`}})))
+	require.Equal(t, `a: duplicated key "note" in struct yaml_test.reflectShadow`, err.Error())
+
+	_, err = yaml.Marshal(vf.Value(reflectMapShadow{More: map[string]int{`a`: 1, `note`: 2}}))
+	require.Equal(t, `duplicated key "note" in struct yaml_test.reflectMapShadow`, err.Error())
+
+	_, err = yaml.Marshal(vf.Value(struct {
+		Note string         `yaml:"note"`
+		More map[string]int `yaml:",inline"`
+	}{More: map[string]int{`note`: 2}}))
+	require.Equal(t, `cannot have key "note" in inlined map: conflicts with struct field`, err.Error())
+
+	_, err = yaml.Marshal(vf.Value(struct {
+		N int `yaml:",inline"`
+	}{}))
+	require.Equal(t, `option ,inline needs a struct value or map field, got int`, err.Error())
+}
+
+func TestMarshal_reflection_marshalers(t *testing.T) {
+	ts, _ := time.Parse(time.RFC3339, `2019-10-06T07:15:00-07:00`)
+	v := vf.Values(
+		vf.Value(reflectLevel(1)),
+		vf.Value(reflectJSON{v: `{"a":[1,"b"]}`}),
+		vf.Value(struct{ T time.Time }{ts}),
+		vf.Value(struct{ B *big.Int }{big.NewInt(7)}),
+		vf.Value(struct{ IP net.IP }{net.ParseIP(`10.0.0.1`)}),
+		vf.Value(&reflectPointerMarshaler{A: testMarshaler{A: `x`}}),
+		vf.Value(struct{ I interface{} }{}),
+		vf.Value(struct{ I interface{} }{reflectLevel(3)}))
+	b, err := yaml.Marshal(v)
+	require.NoError(t, err)
+	require.Equal(t, `- level-1
+- a:
+    - 1
+    - b
+- t: !!timestamp 2019-10-06T07:15:00-07:00
+- b: 7
+- ip: 10.0.0.1
+- a:
+      A: x
+- i: null
+- i: level-3
+`, string(b))
+}
+
+func TestMarshal_reflection_fail(t *testing.T) {
+	_, err := yaml.Marshal(vf.Value(reflectLevel(-1)))
+	require.Error(t, `negative level`, err)
+
+	_, err = yaml.Marshal(vf.Value(reflectJSON{}))
+	require.Error(t, `empty json`, err)
+
+	_, err = yaml.Marshal(vf.Value(reflectJSON{v: `{`}))
+	require.Error(t, `did not find expected node content`, err)
+
+	_, err = yaml.Marshal(vf.Value(struct{ F func() }{func() {}}))
+	require.Error(t, `unable to marshal into value of type func()`, err)
+}
@@ -0,0 +1,55 @@
+package yaml
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tada/dgo/dgo"
+	"github.com/tada/dgo/vf"
+)
+
+// SpecFromType returns a map that describes the given type using the parameter spec layout accepted by
+// tf.StructMapFromMap, i.e. one entry per parameter where each entry is a map with the keys "type", "name",
+// and "required". The names map is optional and provides the value of the "name" entry of each parameter.
+// Parameters that have no name are written without a "name" entry.
+//
+// An error is returned if the type allows additional entries or has keys that aren't strings since
+// such types cannot be described using a parameter spec.
+func SpecFromType(t dgo.StructMapType, names map[string]string) (dgo.Map, error) {
+	if t.Additional() {
+		return nil, errors.New(`a parameter spec cannot describe a type that allows additional entries`)
+	}
+	var err error
+	spec := vf.MapWithCapacity(t.Len())
+	t.EachEntryType(func(e dgo.StructMapEntry) {
+		if err != nil {
+			return
+		}
+		var key string
+		if key, err = specKey(e.Key()); err != nil {
+			return
+		}
+		p := vf.MapWithCapacity(3)
+		p.Put(`type`, e.Value().(dgo.Type).String())
+		if name, ok := names[key]; ok {
+			p.Put(`name`, name)
+		}
+		p.Put(`required`, e.Required())
+		spec.Put(key, p)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// specKey returns the string that is the given key or the exact value of the given key type
+func specKey(kt dgo.Value) (string, error) {
+	if et, ok := kt.(dgo.ExactType); ok {
+		kt = et.ExactValue()
+	}
+	if s, ok := kt.(dgo.String); ok {
+		return s.GoString(), nil
+	}
+	return ``, fmt.Errorf(`a parameter spec cannot describe a key of type %s`, kt.String())
+}
@@ -0,0 +1,92 @@
+package yaml_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/tada/dgo/dgo"
+	"github.com/tada/dgo/test/require"
+	"github.com/tada/dgo/tf"
+	"github.com/tada/dgo/typ"
+	"github.com/tada/dgo/vf"
+	"github.com/tada/dgoyaml/yaml"
+)
+
+func ExampleSpecFromType() {
+	st := tf.ParseType(`{host:string[1],port?:1..999}`).(dgo.StructMapType)
+	spec, err := yaml.SpecFromType(st, map[string]string{`host`: `sample/service_host`})
+	if err == nil {
+		var b []byte
+		if b, err = yaml.MarshalWithOptions(spec, &yaml.EncodeOptions{Indent: 2}); err == nil {
+			fmt.Print(string(b))
+		}
+	}
+	// Output:
+	// host:
+	//   type: string[1]
+	//   name: sample/service_host
+	//   required: true
+	// port:
+	//   type: 1..999
+	//   required: false
+}
+
+func TestSpecFromType_roundTrip(t *testing.T) {
+	m, err := yaml.Unmarshal([]byte(`
+host:
+  type: string[1]
+  name: sample/service_host
+  required: true
+port:
+  type: 1..999
+  required: false
+tags:
+  type: '[]string'
+  required: true
+`))
+	require.NoError(t, err)
+	st := tf.StructMapFromMap(false, m.(dgo.Map))
+	spec, err := yaml.SpecFromType(st, map[string]string{`host`: `sample/service_host`})
+	require.NoError(t, err)
+	require.Equal(t, m, spec)
+	require.Equal(t, st, tf.StructMapFromMap(false, spec))
+}
+
+func TestSpecFromType_fail(t *testing.T) {
+	_, err := yaml.SpecFromType(tf.ParseType(`{a:int,...}`).(dgo.StructMapType), nil)
+	require.Error(t, `a parameter spec cannot describe a type that allows additional entries`, err)
+
+	_, err = yaml.SpecFromType(tf.StructMap(false,
+		tf.StructMapEntry(1, typ.Integer, true), tf.StructMapEntry(2, typ.Integer, true)), nil)
+	require.Error(t, `a parameter spec cannot describe a key of type 1`, err)
+}
+
+func TestMarshalWithOptions_spec(t *testing.T) {
+	v := vf.Map(`params`, tf.ParseType(`{host:string[1],port?:1..999}`), `other`, typ.String)
+	b, err := yaml.MarshalWithOptions(v, &yaml.EncodeOptions{Spec: true})
+	require.NoError(t, err)
+	require.Equal(t, `params:
+    host:
+        type: string[1]
+        required: true
+    port:
+        type: 1..999
+        required: false
+other: !puppet.com,2019:dgo/type string
+`, string(b))
+
+	_, err = yaml.MarshalWithOptions(tf.ParseType(`{a:int,...}`), &yaml.EncodeOptions{Spec: true})
+	require.Error(t, `a parameter spec cannot describe a type that allows additional entries`, err)
+
+	_, err = yaml.MarshalWithOptions(vf.Map(`params`, vf.Values(tf.ParseType(`{a:int,...}`))), &yaml.EncodeOptions{Spec: true})
+	require.Equal(t, `params[0]: a parameter spec cannot describe a type that allows additional entries`, err.Error())
+	var ye *yaml.Error
+	require.True(t, errors.As(err, &ye))
+	require.Equal(t, `params[0]`, ye.Path)
+
+	st := tf.StructMap(false, tf.StructMapEntry(`a`, typ.Integer, true), tf.StructMapEntry(1, typ.Integer, true))
+	_, err = yaml.MarshalWithOptions(vf.Map(`params`, st), &yaml.EncodeOptions{Spec: true})
+	require.Equal(t, `params: a parameter spec cannot describe a key of type 1`, err.Error())
+	require.True(t, errors.As(err, &ye))
+}
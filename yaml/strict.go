@@ -0,0 +1,59 @@
+package yaml
+
+import (
+	"errors"
+
+	"github.com/tada/dgo/vf"
+	y3 "gopkg.in/yaml.v3"
+)
+
+// checkJSON panics with an *Error if the given document node uses YAML features that have no counterpart in
+// the JSON data model. The check is performed before the document is decoded.
+func (d *decoder) checkJSON(n *y3.Node) {
+	if n.Kind == y3.DocumentNode {
+		for _, c := range n.Content {
+			d.checkJSON(c)
+		}
+		return
+	}
+	// Aliases need no check since there can be no aliases without anchors
+	if n.Anchor != `` {
+		d.fail(n, errors.New(`anchors are not allowed in JSON`))
+	}
+	if n.Style&y3.TaggedStyle != 0 {
+		d.fail(n, errors.New(`tags are not allowed in JSON`))
+	}
+	switch n.Kind {
+	case y3.SequenceNode:
+		for i, c := range n.Content {
+			d.path = append(d.path, i)
+			d.checkJSON(c)
+			d.path = d.path[:len(d.path)-1]
+		}
+	case y3.MappingNode:
+		ms := n.Content
+		for i := 0; i < len(ms); i += 2 {
+			kn := ms[i]
+			d.checkJSON(kn)
+			if kn.Kind != y3.ScalarNode || kn.Tag != `!!str` ||
+				kn.Style == 0 && (intPattern.MatchString(kn.Value) || floatPattern.MatchString(kn.Value)) {
+				d.fail(kn, errors.New(`keys must be strings in JSON`))
+			}
+			d.path = append(d.path, vf.String(kn.Value))
+			d.checkJSON(ms[i+1])
+			d.path = d.path[:len(d.path)-1]
+		}
+	default:
+		switch n.Tag {
+		case `!!merge`:
+			d.fail(n, errors.New(`merge keys are not allowed in JSON`))
+		case `!!timestamp`:
+			d.fail(n, errors.New(`timestamps are not allowed in JSON`))
+		case `!!float`:
+			switch n.Value[len(n.Value)-1] {
+			case 'f', 'F', 'n', 'N':
+				d.fail(n, errors.New(`infinity and NaN are not allowed in JSON`))
+			}
+		}
+	}
+}
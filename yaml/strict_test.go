@@ -0,0 +1,60 @@
+package yaml_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/tada/dgo/test/require"
+	"github.com/tada/dgo/vf"
+	"github.com/tada/dgoyaml/yaml"
+)
+
+func ExampleDecodeOptions_strictJSON() {
+	_, err := yaml.UnmarshalWithOptions([]byte(`{"when": 2020-04-01}`), &yaml.DecodeOptions{StrictJSON: true})
+	fmt.Println(err)
+	// Output: 1:10: when: timestamps are not allowed in JSON
+}
+
+func TestUnmarshalWithOptions_strictJSON(t *testing.T) {
+	opts := &yaml.DecodeOptions{StrictJSON: true}
+	v, err := yaml.UnmarshalWithOptions([]byte(`{"a": [1, 2.5, "x", true, null], "b": {"c": "2020-04-01"}, "d": 1e3}`), opts)
+	require.NoError(t, err)
+	require.Equal(t, vf.Map(`a`, vf.Values(1, 2.5, `x`, true, nil), `b`, vf.Map(`c`, `2020-04-01`), `d`, 1000.0), v)
+
+	v, err = yaml.UnmarshalWithOptions([]byte("a: 1\nb: x\n"), opts)
+	require.NoError(t, err)
+	require.Equal(t, vf.Map(`a`, 1, `b`, `x`), v)
+}
+
+func TestUnmarshalWithOptions_strictJSON_fail(t *testing.T) {
+	tests := []struct {
+		source string
+		msg    string
+	}{
+		{`{"a": !!str 1}`, `1:7: a: tags are not allowed in JSON`},
+		{`{"a": !Ref x}`, `1:7: a: tags are not allowed in JSON`},
+		{`{"a": &x [1], "b": 2}`, `1:7: a: anchors are not allowed in JSON`},
+		{"a: &x 1\nb: *x\n", `1:4: a: anchors are not allowed in JSON`},
+		{`{1: "a"}`, `1:2: keys must be strings in JSON`},
+		{`{null: "a"}`, `1:2: keys must be strings in JSON`},
+		{`{[1]: "a"}`, `1:2: keys must be strings in JSON`},
+		{`{123456789012345678901234567890: "a"}`, `1:2: keys must be strings in JSON`},
+		{`{1e400: "a"}`, `1:2: keys must be strings in JSON`},
+		{`{<<: {"a": 1}}`, `1:2: merge keys are not allowed in JSON`},
+		{`["x", 2020-04-01]`, `1:7: [1]: timestamps are not allowed in JSON`},
+		{`[.inf]`, `1:2: [0]: infinity and NaN are not allowed in JSON`},
+		{`[-.Inf]`, `1:2: [0]: infinity and NaN are not allowed in JSON`},
+		{`[.NaN]`, `1:2: [0]: infinity and NaN are not allowed in JSON`},
+	}
+	for _, tt := range tests {
+		_, err := yaml.UnmarshalWithOptions([]byte(tt.source), &yaml.DecodeOptions{StrictJSON: true})
+		require.NotNil(t, err)
+		require.Equal(t, tt.msg, err.Error())
+		var ye *yaml.Error
+		require.True(t, errors.As(err, &ye))
+	}
+
+	_, _, err := yaml.UnmarshalWithPositions(`x.json`, []byte(`{"a": {"b": [.nan]}}`), &yaml.DecodeOptions{StrictJSON: true})
+	require.Equal(t, `x.json:1:14: a.b[0]: infinity and NaN are not allowed in JSON`, err.Error())
+}
@@ -0,0 +1,98 @@
+package yaml
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	y3 "gopkg.in/yaml.v3"
+)
+
+// QuoteStyle determines how string values are quoted when encoded
+type QuoteStyle int
+
+const (
+	// QuoteNone writes strings without quotes unless quotes are required to retain the string type
+	QuoteNone = QuoteStyle(iota)
+
+	// QuoteSingle writes strings within single quotes
+	QuoteSingle
+
+	// QuoteDouble writes strings within double quotes
+	QuoteDouble
+)
+
+// marshal returns the YAML text for the given node
+func (e *encoder) marshal(n *y3.Node) ([]byte, error) {
+	var b bytes.Buffer
+	ye := y3.NewEncoder(&b)
+	ye.SetIndent(e.Indent)
+	err := ye.Encode(n)
+	if err == nil {
+		err = ye.Close()
+	}
+	return b.Bytes(), err
+}
+
+// checkStyle panics with an *Error unless the style options are valid
+func (e *encoder) checkStyle() {
+	if e.Indent != 0 && (e.Indent < 2 || e.Indent > 9) {
+		e.fail(``, fmt.Errorf(`indent must be between 2 and 9, got %d`, e.Indent))
+	}
+	if e.Quote < QuoteNone || e.Quote > QuoteDouble {
+		e.fail(``, fmt.Errorf(`invalid quote style %d`, e.Quote))
+	}
+}
+
+// styled returns true if the options require style changes to the encoded nodes
+func (e *encoder) styled() bool {
+	return e.FlowLength > 0 || e.Quote != QuoteNone || e.YAML11
+}
+
+// style applies the flow, quote, and YAML11 options to the given node and its descendants. Nodes that already
+// have an explicit style are left untouched.
+func (e *encoder) style(n *y3.Node, key bool) {
+	switch n.Kind {
+	case y3.SequenceNode:
+		for _, c := range n.Content {
+			e.style(c, false)
+		}
+		e.flow(n)
+	case y3.MappingNode:
+		for i, c := range n.Content {
+			e.style(c, i%2 == 0)
+		}
+		e.flow(n)
+	case y3.ScalarNode:
+		// Strings that YAML 1.1 parsers would misread are quoted even when they are keys
+		ambiguous := e.YAML11 && yaml11AmbiguousPattern.MatchString(n.Value)
+		if n.Style == 0 && n.Tag == `!!str` && (ambiguous || !key && !strings.Contains(n.Value, "\n")) {
+			switch {
+			case e.Quote == QuoteSingle:
+				n.Style = y3.SingleQuotedStyle
+			case e.Quote == QuoteDouble || ambiguous:
+				n.Style = y3.DoubleQuotedStyle
+			}
+		}
+	}
+}
+
+// flow gives the given sequence or mapping node flow style if it is short and contains only single line scalars
+func (e *encoder) flow(n *y3.Node) {
+	if n.Style != 0 || len(n.Content) == 0 {
+		return
+	}
+	count := len(n.Content)
+	if n.Kind == y3.MappingNode {
+		count /= 2
+	}
+	if count > e.FlowLength {
+		return
+	}
+	for _, c := range n.Content {
+		if c.Kind != y3.ScalarNode || c.Style&(y3.LiteralStyle|y3.FoldedStyle) != 0 || strings.Contains(c.Value, "\n") {
+			return
+		}
+	}
+	n.Style = y3.FlowStyle
+}
@@ -0,0 +1,119 @@
+package yaml
+
+import (
+	"reflect"
+
+	"github.com/tada/dgo/dgo"
+	"github.com/tada/dgo/tf"
+	"github.com/tada/dgo/vf"
+	y3 "gopkg.in/yaml.v3"
+)
+
+// Tagged is a value that was decoded from a node with a tag that is neither a standard YAML tag nor a tag
+// registered using RegisterTag, e.g. the "!Ref" in "!Ref MyBucket". The tag is retained so that the value
+// is written with its original tag when it is marshaled. Tagged values are also returned by Set, OMap,
+// and Pairs.
+type Tagged struct {
+	tag   string
+	value dgo.Value
+}
+
+// taggedType is the dgo.NamedType of all Tagged values
+var taggedType = tf.NewNamed(`yaml.Tagged`,
+	func(arg dgo.Value) dgo.Value {
+		m := arg.(dgo.Map)
+		return NewTagged(m.Get(`tag`).(dgo.String).GoString(), m.Get(`value`))
+	},
+	func(v dgo.Value) dgo.Value {
+		t := v.(*Tagged)
+		return vf.Map(`tag`, t.tag, `value`, t.value)
+	},
+	reflect.TypeOf(&Tagged{}), nil, nil)
+
+// NewTagged returns a Tagged value with the given tag and content. The content is converted to a dgo.Value
+// and may be a scalar, an array, or a map.
+func NewTagged(tag string, value interface{}) *Tagged {
+	return &Tagged{tag: tag, value: vf.Value(value)}
+}
+
+// Tag returns the tag of the value, e.g. "!Ref"
+func (t *Tagged) Tag() string {
+	return t.tag
+}
+
+// Value returns the content of the tagged value
+func (t *Tagged) Value() dgo.Value {
+	return t.value
+}
+
+// String returns the dgo string form of the value
+func (t *Tagged) String() string {
+	return taggedType.ValueString(t)
+}
+
+// Type returns the exact yaml.Tagged type that represents the value
+func (t *Tagged) Type() dgo.Type {
+	return tf.ExactNamed(taggedType, t)
+}
+
+// Equals returns true if the other value is a Tagged with an equal tag and an equal content
+func (t *Tagged) Equals(other interface{}) bool {
+	if ot, ok := other.(*Tagged); ok {
+		return t.tag == ot.tag && t.value.Equals(ot.value)
+	}
+	return false
+}
+
+// HashCode returns the hash code computed from the tag and the content
+func (t *Tagged) HashCode() dgo.Hash {
+	return vf.String(t.tag).HashCode()*31 + t.value.HashCode()
+}
+
+// knownTags are the tags that the decoder handles without the help of a registered decoder
+var knownTags = map[string]bool{
+	``:                          true,
+	`!`:                         true,
+	`!!null`:                    true,
+	`!!bool`:                    true,
+	`!!int`:                     true,
+	`!!float`:                   true,
+	`!!str`:                     true,
+	`!!timestamp`:               true,
+	`!!binary`:                  true,
+	`!!seq`:                     true,
+	`!!map`:                     true,
+	`!!set`:                     true,
+	`!!omap`:                    true,
+	`!!pairs`:                   true,
+	`!puppet.com,2019:dgo/type`: true,
+}
+
+// decodeUnknownTag decodes a node that has an unknown tag into a *Tagged. The content is decoded as if the
+// node had no tag.
+func (d *decoder) decodeUnknownTag(n *y3.Node) dgo.Value {
+	var v dgo.Value
+	switch n.Kind {
+	case y3.SequenceNode:
+		v = d.decodeArray(n)
+	case y3.MappingNode:
+		v = d.decodeMap(n)
+	default:
+		cn := &y3.Node{Kind: n.Kind, Style: n.Style &^ y3.TaggedStyle, Value: n.Value}
+		cn.Tag = cn.ShortTag()
+		v = d.decodeScalar(cn)
+	}
+	t := &Tagged{tag: n.Tag, value: v}
+	d.anchor(n, t)
+	return t
+}
+
+// encodeUnknownTag returns the node for the content of the given value, tagged with the tag of the value
+func (e *encoder) encodeUnknownTag(t *Tagged) *y3.Node {
+	n := e.yamlEncodeValue(t.value)
+	if n.Kind == y3.ScalarNode && n.Style == 0 && n.Tag == `!!str` && (&y3.Node{Kind: y3.ScalarNode, Value: n.Value}).ShortTag() != `!!str` {
+		// The string would not be read back as a string unless quoted once the tag is replaced
+		n.Style = y3.DoubleQuotedStyle
+	}
+	n.Tag = t.tag
+	return n
+}
@@ -0,0 +1,99 @@
+package yaml_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tada/dgo/dgo"
+	"github.com/tada/dgo/test/require"
+	"github.com/tada/dgo/tf"
+	"github.com/tada/dgo/vf"
+	"github.com/tada/dgoyaml/yaml"
+)
+
+func ExampleTagged() {
+	v, err := yaml.Unmarshal([]byte(`
+Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+Outputs:
+  Name:
+    Value: !Ref Bucket
+  Arn:
+    Value: !GetAtt [Bucket, Arn]
+`))
+	if err != nil {
+		return
+	}
+	ref := v.(dgo.Map).Get(`Outputs`).(dgo.Map).Get(`Name`).(dgo.Map).Get(`Value`).(*yaml.Tagged)
+	fmt.Println(ref.Tag(), ref.Value())
+
+	b, err := yaml.MarshalWithOptions(v, &yaml.EncodeOptions{Indent: 2})
+	if err == nil {
+		fmt.Print(string(b))
+	}
+	// Output:
+	// !Ref Bucket
+	// Resources:
+	//   Bucket:
+	//     Type: AWS::S3::Bucket
+	// Outputs:
+	//   Name:
+	//     Value: !Ref Bucket
+	//   Arn:
+	//     Value: !GetAtt
+	//     - Bucket
+	//     - Arn
+}
+
+func TestUnmarshal_unknownTags(t *testing.T) {
+	v, err := yaml.Unmarshal([]byte(`
+a: !secret db_password
+b: !include {file: x.yaml}
+c: !env_var 8080
+d: !quoted "8080"
+e: !!custom x
+f: &f !Ref x
+g: *f
+`))
+	require.NoError(t, err)
+	m := v.(dgo.Map)
+	require.Equal(t, yaml.NewTagged(`!secret`, `db_password`), m.Get(`a`))
+	require.Equal(t, yaml.NewTagged(`!include`, vf.Map(`file`, `x.yaml`)), m.Get(`b`))
+	require.Equal(t, yaml.NewTagged(`!env_var`, 8080), m.Get(`c`))
+	require.Equal(t, yaml.NewTagged(`!quoted`, `8080`), m.Get(`d`))
+	require.Equal(t, yaml.NewTagged(`!!custom`, `x`), m.Get(`e`))
+	require.Same(t, m.Get(`f`), m.Get(`g`))
+
+	b, err := yaml.Marshal(v)
+	require.NoError(t, err)
+	require.Equal(t, `a: !secret db_password
+b: !include
+    file: x.yaml
+c: !env_var 8080
+d: !quoted "8080"
+e: !!custom x
+f: !Ref x
+g: !Ref x
+`, string(b))
+
+	rv, err := yaml.Unmarshal(b)
+	require.NoError(t, err)
+	require.Equal(t, v, rv)
+}
+
+func TestTagged(t *testing.T) {
+	a := yaml.NewTagged(`!Ref`, `x`)
+	require.Equal(t, `!Ref`, a.Tag())
+	require.Equal(t, `x`, a.Value())
+	require.Equal(t, a, yaml.NewTagged(`!Ref`, `x`))
+	require.NotEqual(t, a, yaml.NewTagged(`!Sub`, `x`))
+	require.NotEqual(t, a, yaml.NewTagged(`!Ref`, `y`))
+	require.NotEqual(t, a, `x`)
+	require.Equal(t, a.HashCode(), yaml.NewTagged(`!Ref`, `x`).HashCode())
+	require.Equal(t, `yaml.Tagged{"tag":"!Ref","value":"x"}`, a.String())
+	require.Equal(t, `yaml.Tagged`, tf.Named(`yaml.Tagged`).String())
+	require.True(t, tf.Named(`yaml.Tagged`).Instance(a))
+	require.Equal(t, a, tf.Named(`yaml.Tagged`).New(vf.Map(`tag`, `!Ref`, `value`, `x`)))
+	require.True(t, a.Type().Instance(a))
+}
@@ -0,0 +1,88 @@
+package yaml
+
+import (
+	"sync"
+
+	"github.com/tada/dgo/dgo"
+	y3 "gopkg.in/yaml.v3"
+)
+
+// tagHandler holds the functions registered for a YAML tag
+type tagHandler struct {
+	tag    string
+	decode func(*y3.Node) (dgo.Value, error)
+	encode func(dgo.Value) (*y3.Node, bool)
+}
+
+var (
+	tagLock     sync.RWMutex
+	tagDecoders = make(map[string]*tagHandler)
+	tagEncoders []*tagHandler
+)
+
+// RegisterTag registers functions that decode and encode values for the given YAML tag, e.g. "!duration". A
+// registration for a tag that is already registered replaces the previous registration.
+//
+// The decode function is called for every scalar, sequence, and mapping node that has the tag and must return
+// the value that the node represents. Registered decode functions take precedence over the built-in decoding.
+//
+// The encode function is called for every value that is encoded, in registration order, before the built-in
+// encoding is attempted. It must return false when it doesn't handle the given value. The registered tag is
+// assigned to the returned node unless the node already has a tag.
+//
+// Either function may be nil.
+func RegisterTag(tag string, decode func(*y3.Node) (dgo.Value, error), encode func(dgo.Value) (*y3.Node, bool)) {
+	th := &tagHandler{tag: tag, decode: decode, encode: encode}
+	tagLock.Lock()
+	defer tagLock.Unlock()
+
+	encoders := make([]*tagHandler, 0, len(tagEncoders)+1)
+	for _, e := range tagEncoders {
+		if e.tag != tag {
+			encoders = append(encoders, e)
+		}
+	}
+	if encode != nil {
+		encoders = append(encoders, th)
+	}
+	tagEncoders = encoders
+
+	if decode != nil {
+		tagDecoders[tag] = th
+	} else {
+		delete(tagDecoders, tag)
+	}
+}
+
+// decodeTagged decodes the given node using the decode function registered for its tag. It returns false when
+// no such function is registered.
+func (d *decoder) decodeTagged(n *y3.Node) (dgo.Value, bool) {
+	tagLock.RLock()
+	th, ok := tagDecoders[n.Tag]
+	tagLock.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	v, err := th.decode(n)
+	if err != nil {
+		d.fail(n, err)
+	}
+	return v, true
+}
+
+// encodeTagged returns the node produced by the first registered encode function that handles the given value,
+// or nil when no such function exists.
+func encodeTagged(v dgo.Value) *y3.Node {
+	tagLock.RLock()
+	encoders := tagEncoders
+	tagLock.RUnlock()
+	for _, th := range encoders {
+		if n, ok := th.encode(v); ok {
+			if n.Tag == `` {
+				n.Tag = th.tag
+			}
+			return n
+		}
+	}
+	return nil
+}
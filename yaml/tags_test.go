@@ -0,0 +1,132 @@
+package yaml_test
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/tada/dgo/dgo"
+	"github.com/tada/dgo/test/require"
+	"github.com/tada/dgo/vf"
+	"github.com/tada/dgoyaml/yaml"
+	y3 "gopkg.in/yaml.v3"
+)
+
+func init() {
+	yaml.RegisterTag(`!duration`,
+		func(n *y3.Node) (dgo.Value, error) {
+			d, err := time.ParseDuration(n.Value)
+			if err != nil {
+				return nil, err
+			}
+			return vf.Value(d), nil
+		},
+		func(v dgo.Value) (*y3.Node, bool) {
+			if nv, ok := v.(dgo.Native); ok {
+				if d, ok := nv.GoValue().(time.Duration); ok {
+					return &y3.Node{Kind: y3.ScalarNode, Value: d.String()}, true
+				}
+			}
+			return nil, false
+		})
+}
+
+func ExampleRegisterTag() {
+	yaml.RegisterTag(`!regexp`,
+		func(n *y3.Node) (dgo.Value, error) {
+			rx, err := regexp.Compile(n.Value)
+			if err != nil {
+				return nil, err
+			}
+			return vf.Regexp(rx), nil
+		},
+		func(v dgo.Value) (*y3.Node, bool) {
+			if rx, ok := v.(dgo.Regexp); ok {
+				return &y3.Node{Kind: y3.ScalarNode, Value: rx.GoRegexp().String()}, true
+			}
+			return nil, false
+		})
+
+	v, err := yaml.Unmarshal([]byte(`match: !regexp '^[a-z]+$'`))
+	if err == nil {
+		rx := v.(dgo.Map).Get(`match`).(dgo.Regexp).GoRegexp()
+		fmt.Println(rx.MatchString(`abc`), rx.MatchString(`ABC`))
+		bs, _ := yaml.Marshal(v)
+		fmt.Print(string(bs))
+	}
+	// Output:
+	// true false
+	// match: !regexp ^[a-z]+$
+}
+
+func TestRegisterTag_scalar(t *testing.T) {
+	v, err := yaml.Unmarshal([]byte(`timeout: !duration 1m30s`))
+	require.NoError(t, err)
+	require.Equal(t, vf.Map(`timeout`, vf.Value(90*time.Second)), v)
+
+	bs, err := yaml.Marshal(v)
+	require.NoError(t, err)
+	require.Equal(t, "timeout: !duration 1m30s\n", string(bs))
+
+	_, err = yaml.Unmarshal([]byte(`timeout: !duration forever`))
+	require.Error(t, `invalid duration`, err)
+}
+
+func TestRegisterTag_mapping(t *testing.T) {
+	yaml.RegisterTag(`!login`,
+		func(n *y3.Node) (dgo.Value, error) {
+			var s struct {
+				User     string `yaml:"user"`
+				Password string `yaml:"password"`
+			}
+			if err := n.Decode(&s); err != nil {
+				return nil, err
+			}
+			if s.User == `` {
+				return nil, errors.New(`login without user`)
+			}
+			return vf.Sensitive(s.User + `:` + s.Password), nil
+		},
+		func(v dgo.Value) (*y3.Node, bool) {
+			if _, ok := v.(dgo.Sensitive); ok {
+				return &y3.Node{Kind: y3.ScalarNode, Tag: `!redacted`, Value: `***`}, true
+			}
+			return nil, false
+		})
+
+	v, err := yaml.Unmarshal([]byte(`login: !login {user: bob, password: secret}`))
+	require.NoError(t, err)
+	s, ok := v.(dgo.Map).Get(`login`).(dgo.Sensitive)
+	require.True(t, ok)
+	require.Equal(t, `bob:secret`, s.Unwrap())
+
+	bs, err := yaml.Marshal(v)
+	require.NoError(t, err)
+	require.Equal(t, "login: !redacted '***'\n", string(bs))
+
+	_, err = yaml.Unmarshal([]byte(`login: !login {password: secret}`))
+	require.Error(t, `login without user`, err)
+}
+
+func TestRegisterTag_replace(t *testing.T) {
+	yaml.RegisterTag(`!upper`,
+		func(n *y3.Node) (dgo.Value, error) {
+			return vf.String(n.Value + `!`), nil
+		}, nil)
+	v, err := yaml.Unmarshal([]byte(`a: !upper x`))
+	require.NoError(t, err)
+	require.Equal(t, vf.Map(`a`, `x!`), v)
+
+	yaml.RegisterTag(`!upper`, nil, nil)
+	v, err = yaml.Unmarshal([]byte(`a: !upper x`))
+	require.NoError(t, err)
+	require.Equal(t, vf.Map(`a`, yaml.NewTagged(`!upper`, `x`)), v)
+}
+
+func TestRegisterTag_anchor(t *testing.T) {
+	v, err := yaml.Unmarshal([]byte("a: &d !duration 1s\nb: *d\n"))
+	require.NoError(t, err)
+	require.Equal(t, vf.Map(`a`, vf.Value(time.Second), `b`, vf.Value(time.Second)), v)
+}
@@ -0,0 +1,252 @@
+package yaml
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/tada/dgo/dgo"
+	"github.com/tada/dgo/typ"
+	"github.com/tada/dgo/vf"
+	y3 "gopkg.in/yaml.v3"
+)
+
+// Violation describes a value in a YAML document that doesn't match its expected type
+type Violation struct {
+	// Path is the path of the value, see Positions for a description of paths
+	Path string
+
+	// Position is the position of the offending value or key
+	Position Position
+
+	// Message describes the violation
+	Message string
+}
+
+// Error returns the violation in the form "position: path: message"
+func (v *Violation) Error() string {
+	return v.Position.String() + `: ` + pathLabel(v.Path) + `: ` + v.Message
+}
+
+// pathLabel returns the given path, or "(root)" if the path is empty
+func pathLabel(path string) string {
+	if path == `` {
+		return `(root)`
+	}
+	return path
+}
+
+// ValidationError is returned when a decoded document doesn't match its expected type. It contains all
+// violations found in the document.
+type ValidationError struct {
+	Violations []*Violation
+}
+
+// Error returns the errors of all violations separated by newline
+func (e *ValidationError) Error() string {
+	s := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		s[i] = v.Error()
+	}
+	return strings.Join(s, "\n")
+}
+
+// Coercion describes a scalar that was converted to match its expected type when decoding with the Coerce
+// option
+type Coercion struct {
+	// Path is the path of the value, see Positions for a description of paths
+	Path string
+
+	// Position is the position of the scalar
+	Position Position
+
+	// From is the value that the scalar was resolved to
+	From dgo.Value
+
+	// To is the value that the scalar was converted to
+	To dgo.Value
+}
+
+// String returns the coercion in the form "position: path: converted <from> to <to>"
+func (c *Coercion) String() string {
+	return c.Position.String() + `: ` + pathLabel(c.Path) + `: converted ` + c.From.String() + ` to ` + c.To.String()
+}
+
+// UnmarshalAs decodes the YAML representation of the given bytes into a dgo.Value that is an instance of the
+// given type. The type directs the decoding so that each value is checked against its expected type while it
+// is decoded, and subtrees that cannot match their expected type are not decoded at all. A *ValidationError
+// that contains all violations is returned when the document doesn't match the type.
+func UnmarshalAs(b []byte, t dgo.Type) (dgo.Value, error) {
+	return UnmarshalWithOptions(b, &DecodeOptions{Type: t})
+}
+
+// violation records a violation for the current path at the position of the given node
+func (d *decoder) violation(n *y3.Node, format string, args ...interface{}) {
+	d.violations = append(d.violations, &Violation{
+		Path:     pathString(d.path),
+		Position: d.position(n),
+		Message:  fmt.Sprintf(format, args...)})
+}
+
+// decodeAs decodes the given node into a value that is expected to be an instance of the given type. A
+// violation is recorded when it isn't.
+func (d *decoder) decodeAs(n *y3.Node, t dgo.Type) dgo.Value {
+	if n.Kind == y3.DocumentNode {
+		n = n.Content[0]
+	}
+	if !nodeCanMatch(n, t) {
+		d.violation(n, `expected a value of type %s, got %s`, t, kindName(n))
+		return vf.Nil
+	}
+	mark := len(d.violations)
+	var v dgo.Value
+	switch {
+	case n.Tag == `!!map`:
+		switch t := t.(type) {
+		case dgo.StructMapType:
+			v = d.decodeStructAs(n, t)
+		case dgo.MapType:
+			v = d.decodeMapAs(n, t)
+		}
+	case n.Tag == `!!seq`:
+		if at, ok := t.(dgo.ArrayType); ok {
+			v = d.decodeArrayWith(n, func(i int, en *y3.Node) dgo.Value { return d.decodeAs(en, elementType(at, i)) })
+		}
+	}
+	if v == nil {
+		v = d.decodeValue(n)
+	} else {
+		d.record(n)
+	}
+	if len(d.violations) == mark && !t.Instance(v) {
+		if cv := d.coerce(n, t, v); cv != nil {
+			return cv
+		}
+		d.violation(n, `expected a value of type %s, got %s`, t, v.String())
+	}
+	return v
+}
+
+// coerce returns the value that the lexical form of the given scalar node is converted to in order to match
+// the given type, or nil if the Coerce option isn't set or no such value exists
+func (d *decoder) coerce(n *y3.Node, t dgo.Type, v dgo.Value) dgo.Value {
+	if !d.Coerce || n.Kind != y3.ScalarNode || n.Tag == `!!null` || n.Style&y3.TaggedStyle != 0 {
+		return nil
+	}
+	for _, cv := range coercionCandidates(n.Value) {
+		if t.Instance(cv) {
+			if d.OnCoerce != nil {
+				d.OnCoerce(&Coercion{
+					Path:     pathString(d.path),
+					Position: d.position(n),
+					From:     v,
+					To:       cv})
+			}
+			return cv
+		}
+	}
+	return nil
+}
+
+// coercionCandidates returns the values that the given lexical form of a scalar can be converted to
+func coercionCandidates(s string) []dgo.Value {
+	cs := []dgo.Value{vf.String(s)}
+	if x, err := strconv.ParseInt(s, 0, 64); err == nil {
+		cs = append(cs, vf.Integer(x))
+	} else if bi, ok := new(big.Int).SetString(s, 0); ok {
+		cs = append(cs, vf.BigInt(bi))
+	}
+	if x, err := strconv.ParseFloat(s, 64); err == nil {
+		cs = append(cs, vf.Float(x))
+	}
+	switch s {
+	case `true`, `True`, `TRUE`:
+		cs = append(cs, vf.True)
+	case `false`, `False`, `FALSE`:
+		cs = append(cs, vf.False)
+	}
+	return cs
+}
+
+// decodeStructAs decodes a mapping node with entries that are expected to match the given struct type
+func (d *decoder) decodeStructAs(n *y3.Node, t dgo.StructMapType) dgo.Map {
+	m := d.decodeMapWith(n, func(k dgo.Value, kn, vn *y3.Node) dgo.Value {
+		if e := t.GetEntryType(k); e != nil {
+			return d.decodeAs(vn, e.Value().(dgo.Type))
+		}
+		if !t.Additional() {
+			d.violation(kn, `unknown key %s`, k.String())
+			return vf.Nil
+		}
+		return d.decodeValue(vn)
+	})
+	t.EachEntryType(func(e dgo.StructMapEntry) {
+		if k := e.Key().(dgo.ExactType).ExactValue(); e.Required() && !m.ContainsKey(k) {
+			d.violation(n, `missing required key %s`, k.String())
+		}
+	})
+	return m
+}
+
+// decodeMapAs decodes a mapping node with keys and values that are expected to match the given map type
+func (d *decoder) decodeMapAs(n *y3.Node, t dgo.MapType) dgo.Map {
+	kt := t.KeyType()
+	vt := t.ValueType()
+	return d.decodeMapWith(n, func(k dgo.Value, kn, vn *y3.Node) dgo.Value {
+		if !kt.Instance(k) {
+			d.violation(kn, `expected a key of type %s, got %s`, kt, k.String())
+			return vf.Nil
+		}
+		return d.decodeAs(vn, vt)
+	})
+}
+
+// elementType returns the expected type of the element at the given index of an instance of the given type
+func elementType(t dgo.ArrayType, i int) dgo.Type {
+	tt, ok := t.(dgo.TupleType)
+	if !ok {
+		return t.ElementType()
+	}
+	last := tt.Len() - 1
+	switch {
+	case tt.Variadic() && i >= last:
+		return tt.ElementTypeAt(last)
+	case i <= last:
+		return tt.ElementTypeAt(i)
+	default:
+		return typ.Any
+	}
+}
+
+// nodeCanMatch returns false when the kind of the given node rules out that it is decoded into an instance
+// of the given type
+func nodeCanMatch(n *y3.Node, t dgo.Type) bool {
+	switch n.Tag {
+	case `!!map`, `!!seq`, `!!str`, `!!int`, `!!float`, `!!bool`, `!!null`, `!!timestamp`, `!!binary`:
+	default:
+		// Aliases and custom tags may produce anything
+		return true
+	}
+	switch t.(type) {
+	case dgo.MapType:
+		return n.Tag == `!!map`
+	case dgo.ArrayType:
+		return n.Tag == `!!seq`
+	case dgo.IntegerType, dgo.FloatType, dgo.StringType, dgo.BooleanType, dgo.TimeType:
+		return n.Kind == y3.ScalarNode
+	}
+	return true
+}
+
+// kindName returns a description of the given node that is suitable for a violation message
+func kindName(n *y3.Node) string {
+	switch n.Kind {
+	case y3.MappingNode:
+		return `a mapping`
+	case y3.SequenceNode:
+		return `a sequence`
+	default:
+		return `a scalar`
+	}
+}
@@ -0,0 +1,214 @@
+package yaml_test
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/tada/dgo/test/require"
+	"github.com/tada/dgo/tf"
+	"github.com/tada/dgo/typ"
+	"github.com/tada/dgo/vf"
+	"github.com/tada/dgoyaml/yaml"
+)
+
+func ExampleUnmarshalAs() {
+	_, err := yaml.UnmarshalAs([]byte(`
+host: example.com
+port: 2222
+login: bob
+`), tf.ParseType(`{host:string[1],port?:1..999}`))
+	fmt.Println(err)
+	// Output:
+	// 3:7: port: expected a value of type 1..999, got 2222
+	// 4:1: login: unknown key "login"
+}
+
+func TestUnmarshalAs(t *testing.T) {
+	st := tf.ParseType(`{host:string[1],port?:1..999,tags?:[]string,env?:map[string]string,pair?:{int,string},rest?:{string,...int}}`)
+	v, err := yaml.UnmarshalAs([]byte(`
+host: example.com
+port: 22
+tags: [a, b]
+env: {A: x}
+pair: [1, one]
+rest: [x, 1, 2]
+`), st)
+	require.NoError(t, err)
+	require.Equal(t, vf.Map(
+		`host`, `example.com`,
+		`port`, 22,
+		`tags`, vf.Values(`a`, `b`),
+		`env`, vf.Map(`A`, `x`),
+		`pair`, vf.Values(1, `one`),
+		`rest`, vf.Values(`x`, 1, 2)), v)
+}
+
+func TestUnmarshalAs_violations(t *testing.T) {
+	st := tf.ParseType(`{host:string[1],port?:1..999,tags?:[]string,env?:map[string]string,pair?:{int,string},rest?:{string,...int}}`)
+	_, err := yaml.UnmarshalAs([]byte(`
+port: 2222
+tags: [a, 3, {x: y}]
+env: {1: x, B: [y]}
+pair: [1, one, 2]
+rest: [x, 1, b]
+extra:
+  deep: value
+`), st)
+	var ve *yaml.ValidationError
+	require.True(t, errors.As(err, &ve))
+	require.Equal(t, `2:7: port: expected a value of type 1..999, got 2222
+3:11: tags[1]: expected a value of type string, got 3
+3:14: tags[2]: expected a value of type string, got a mapping
+4:7: env.1: expected a key of type string, got 1
+4:16: env.B: expected a value of type string, got a sequence
+5:7: pair: expected a value of type {int,string}, got {1,"one",2}
+6:14: rest[2]: expected a value of type int, got "b"
+7:1: extra: unknown key "extra"
+2:1: (root): missing required key "host"`, err.Error())
+	require.Equal(t, 9, len(ve.Violations))
+	require.Equal(t, yaml.Position{Line: 2, Column: 7}, ve.Violations[0].Position)
+	require.Equal(t, `port`, ve.Violations[0].Path)
+}
+
+func TestUnmarshalAs_root(t *testing.T) {
+	_, err := yaml.UnmarshalAs([]byte(`[1, 2]`), tf.ParseType(`map[string]int`))
+	require.Error(t, `1:1: \(root\): expected a value of type map\[string\]int, got a sequence`, err)
+
+	_, err = yaml.UnmarshalAs([]byte(`a`), tf.ParseType(`[]string`))
+	require.Error(t, `1:1: \(root\): expected a value of type \[\]string, got a scalar`, err)
+
+	_, err = yaml.UnmarshalAs([]byte(`a: b`), typ.String)
+	require.Error(t, `1:1: \(root\): expected a value of type string, got a mapping`, err)
+
+	_, err = yaml.UnmarshalAs([]byte(`[1, 2]`), tf.ParseType(`[0,1]int`))
+	require.Error(t, `1:1: \(root\): expected a value of type \[0,1\]int, got {1,2}`, err)
+
+	v, err := yaml.UnmarshalAs([]byte(`[1, a]`), typ.Any)
+	require.NoError(t, err)
+	require.Equal(t, vf.Values(1, `a`), v)
+}
+
+func TestUnmarshalAs_additional(t *testing.T) {
+	st := tf.ParseType(`{host:string,...}`)
+	v, err := yaml.UnmarshalAs([]byte("host: x\nextra: [1]\n"), st)
+	require.NoError(t, err)
+	require.Equal(t, vf.Map(`host`, `x`, `extra`, vf.Values(1)), v)
+}
+
+func TestUnmarshalAs_alias(t *testing.T) {
+	st := tf.ParseType(`{a:[]int,b:[]int}`)
+	v, err := yaml.UnmarshalAs([]byte("a: &x [1]\nb: *x\n"), st)
+	require.NoError(t, err)
+	require.Equal(t, vf.Map(`a`, vf.Values(1), `b`, vf.Values(1)), v)
+
+	_, err = yaml.UnmarshalAs([]byte("a: &x [1]\nb: {<<: *x}\n"), st)
+	require.Error(t, `2:4: b: expected a value of type \[\]int, got a mapping`, err)
+
+	_, err = yaml.UnmarshalAs([]byte("a: &x [s]\nb: *x\n"), st)
+	require.Error(t, `1:8: a\[0\]: expected a value of type int, got "s"\n2:4: b: expected a value of type \[\]int, got {"s"}`, err)
+}
+
+func TestUnmarshalAs_merge(t *testing.T) {
+	st := tf.ParseType(`{a:{x:int},b:{x:int,y:int}}`)
+	v, err := yaml.UnmarshalAs([]byte("a: &m {x: 1}\nb: {<<: *m, y: 2}\n"), st)
+	require.NoError(t, err)
+	require.Equal(t, vf.Map(`a`, vf.Map(`x`, 1), `b`, vf.Map(`x`, 1, `y`, 2)), v)
+
+	_, err = yaml.UnmarshalAs([]byte("a: &m {x: 1}\nb: {<<: *m, y: s}\n"), st)
+	require.Error(t, `2:16: b.y: expected a value of type int, got "s"`, err)
+
+	_, err = yaml.UnmarshalAs([]byte("a: {x: 1}\nb: {<<: {x: s}, y: 1}\n"), st)
+	require.Error(t, `2:4: b: expected a value of type {"x":int,"y":int}, got {"x":"s","y":1}`, err)
+}
+
+func TestUnmarshalWithPositions_type(t *testing.T) {
+	_, ps, err := yaml.UnmarshalWithPositions(`test.yaml`, []byte("a: [1]\n"), &yaml.DecodeOptions{Type: tf.ParseType(`{a:[]int}`)})
+	require.NoError(t, err)
+	pos, ok := ps.Value(`a`)
+	require.True(t, ok)
+	require.Equal(t, `test.yaml:1:4`, pos.String())
+}
+
+func TestUnmarshalWithPositions_typeFile(t *testing.T) {
+	opts := &yaml.DecodeOptions{Type: tf.ParseType(`{host:string,port:1..999}`)}
+	_, _, err := yaml.UnmarshalWithPositions(`f.yaml`, []byte("host: example.com\nport: 2222\n"), opts)
+	require.Equal(t, `f.yaml:2:7: port: expected a value of type 1..999, got 2222`, err.Error())
+
+	var cs []*yaml.Coercion
+	opts.Coerce = true
+	opts.OnCoerce = func(c *yaml.Coercion) { cs = append(cs, c) }
+	_, _, err = yaml.UnmarshalWithPositions(`f.yaml`, []byte("host: example.com\nport: '22'\n"), opts)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(cs))
+	require.Equal(t, `f.yaml:2:7: port: converted "22" to 22`, cs[0].String())
+}
+
+func ExampleDecodeOptions_coerce() {
+	var coercions []string
+	v, err := yaml.UnmarshalWithOptions([]byte(`
+port: "22"
+version: 1.10
+`), &yaml.DecodeOptions{
+		Type:     tf.ParseType(`{port:1..999,version:string}`),
+		Coerce:   true,
+		OnCoerce: func(c *yaml.Coercion) { coercions = append(coercions, c.String()) }})
+	if err == nil {
+		fmt.Println(v)
+		for _, c := range coercions {
+			fmt.Println(c)
+		}
+	}
+	// Output:
+	// map[port:22 version:1.10]
+	// 2:7: port: converted "22" to 22
+	// 3:10: version: converted 1.1 to "1.10"
+}
+
+func TestUnmarshalWithOptions_coerce(t *testing.T) {
+	st := tf.ParseType(`{a:int,b:float,c:bool,d:string,f:string,g:bool,h:int}`)
+	var cs []*yaml.Coercion
+	v, err := yaml.UnmarshalWithOptions([]byte(`
+a: "0x10"
+b: "1e3"
+c: "TRUE"
+d: 2001-12-14
+f: false
+g: "False"
+h: 3
+`), &yaml.DecodeOptions{Type: st, Coerce: true, OnCoerce: func(c *yaml.Coercion) { cs = append(cs, c) }})
+	require.NoError(t, err)
+	require.Equal(t, vf.Map(`a`, 16, `b`, 1000.0, `c`, true, `d`, `2001-12-14`, `f`, `false`, `g`, false, `h`, 3), v)
+	require.Equal(t, 6, len(cs))
+	require.Equal(t, `a`, cs[0].Path)
+	require.Equal(t, yaml.Position{Line: 2, Column: 4}, cs[0].Position)
+	require.Equal(t, `0x10`, cs[0].From)
+	require.Equal(t, 16, cs[0].To)
+
+	v, err = yaml.UnmarshalWithOptions([]byte(`"22"`), &yaml.DecodeOptions{Type: typ.Integer, Coerce: true})
+	require.NoError(t, err)
+	require.Equal(t, 22, v)
+
+	v, err = yaml.UnmarshalWithOptions([]byte(`"18446744073709551616"`), &yaml.DecodeOptions{Type: typ.BigInt, Coerce: true})
+	require.NoError(t, err)
+	bi, _ := new(big.Int).SetString(`18446744073709551616`, 10)
+	require.Equal(t, vf.BigInt(bi), v)
+}
+
+func TestUnmarshalWithOptions_coerce_fail(t *testing.T) {
+	st := tf.ParseType(`{a:int,b:string,c:string,d:1..10}`)
+	_, err := yaml.UnmarshalWithOptions([]byte(`
+a: "x"
+b: !!int 3
+c: null
+d: "22"
+`), &yaml.DecodeOptions{Type: st, Coerce: true})
+	require.Error(t, `2:4: a: expected a value of type int, got "x"
+3:4: b: expected a value of type string, got 3
+4:4: c: expected a value of type string, got nil
+5:4: d: expected a value of type 1..10, got "22"`, err)
+
+	_, err = yaml.UnmarshalAs([]byte(`a: "22"`), tf.ParseType(`{a:int}`))
+	require.Error(t, `1:4: a: expected a value of type int, got "22"`, err)
+}
@@ -1,6 +1,13 @@
 package yaml
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/tada/dgo/dgo"
@@ -14,28 +21,193 @@ type yamlError struct {
 	error
 }
 
+// recoverYamlError recovers a yamlError panic and assigns its error to the given pointer. Any other panic
+// is propagated. It must be called using defer.
+func recoverYamlError(err *error) {
+	if r := recover(); r != nil {
+		if ye, ok := r.(yamlError); ok {
+			*err = ye.error
+		} else {
+			panic(r)
+		}
+	}
+}
+
+// DecodeOptions controls how YAML is decoded into dgo.Values. The zero value gives the default behavior.
+type DecodeOptions struct {
+	// BigFloats causes all floats to be decoded as dgo.BigFloat values with a precision that retains all
+	// digits of the source. Floats that are too large for a float64 are always decoded as dgo.BigFloat.
+	BigFloats bool
+
+	// Type is the expected type of the decoded document. When set, each value is checked against its expected
+	// type while it is decoded and a *ValidationError is returned when one or more values don't match.
+	Type dgo.Type
+
+	// Coerce enables conversion of scalars that don't match their expected type given by the Type option. The
+	// lexical form of such a scalar is converted to a string, integer, float, or boolean (in that order of
+	// preference) that matches the expected type. Explicitly tagged scalars and nulls are never converted.
+	Coerce bool
+
+	// OnCoerce, when set, is called for each scalar that is converted due to the Coerce option
+	OnCoerce func(*Coercion)
+
+	// AllowDuplicateKeys disables the *DuplicateKeyError that is otherwise returned when a mapping contains the
+	// same key more than once. The last occurrence of the key wins when duplicates are allowed.
+	AllowDuplicateKeys bool
+
+	// MaxInputSize is the maximum size in bytes of the input. It is checked before the input is parsed. The
+	// size of a stream read by a Decoder is the total number of bytes read from it so far, which may include
+	// bytes of documents that follow the one being decoded. A *LimitError is returned for input that exceeds it.
+	// Zero means no limit.
+	MaxInputSize int
+
+	// MaxDepth is the maximum number of nested sequences and mappings. A *LimitError is returned for documents
+	// that exceed it. Zero means no limit.
+	//
+	// MaxDepth, MaxNodes, MaxScalarLength, and MaxAliasExpansion only bound the decoding of a document into
+	// dgo.Values. They are checked after the YAML parser has built the complete node tree of the document, so
+	// they don't bound the memory and time that parsing takes. Use MaxInputSize to bound the parsing.
+	MaxDepth int
+
+	// MaxNodes is the maximum number of nodes in a document. Zero means no limit.
+	MaxNodes int
+
+	// MaxScalarLength is the maximum length in bytes of a scalar. Zero means no limit.
+	MaxScalarLength int
+
+	// MaxAliasExpansion is the maximum total number of nodes that the aliases of a document may expand to if
+	// each alias is replaced by a copy of the node that it refers to. It guards against "billion laughs" documents
+	// that would otherwise exhaust memory when the decoded value is traversed. Zero means no limit.
+	MaxAliasExpansion int
+
+	// YAML11 causes plain scalars to be resolved using the rules of YAML 1.1 where they differ from those of
+	// YAML 1.2, so that "yes", "on", "no", and "off" in any of their capitalizations are booleans. Numbers with a
+	// leading zero such as "0755" are octal integers regardless of this option.
+	YAML11 bool
+
+	// StrictJSON restricts the input to the JSON data model. An *Error is returned when the document contains
+	// tags, anchors and aliases, merge keys, keys that aren't strings, plain scalars that resolve to timestamps,
+	// or infinite and NaN floats.
+	StrictJSON bool
+}
+
+// DuplicateKeyError is returned when a mapping contains the same key more than once. Keys are compared using
+// dgo equality.
+type DuplicateKeyError struct {
+	// Key is the duplicated key
+	Key dgo.Value
+
+	// Path is the path of the value of the duplicated key, see Positions for a description of paths
+	Path string
+
+	// Position is the position of the duplicate
+	Position Position
+
+	// Previous is the position of the first occurrence of the key
+	Previous Position
+}
+
+// Error returns a message that contains the positions of both occurrences of the key
+func (e *DuplicateKeyError) Error() string {
+	return e.Position.String() + `: duplicate key ` + e.Key.String() + `, previously defined at ` + e.Previous.String()
+}
+
 // Unmarshal decodes the YAML representation of the given bytes into a dgo.Value
 func Unmarshal(b []byte) (val dgo.Value, err error) {
+	return UnmarshalWithOptions(b, nil)
+}
+
+// UnmarshalWithOptions decodes the YAML representation of the given bytes into a dgo.Value using the
+// given options. A nil options pointer gives the same result as Unmarshal.
+func UnmarshalWithOptions(b []byte, opts *DecodeOptions) (val dgo.Value, err error) {
+	if err = checkInputSize(``, b, opts); err != nil {
+		return
+	}
 	var n y3.Node
 	if err = y3.Unmarshal(b, &n); err != nil {
+		err = syntaxError(``, err)
 		return
 	}
-	defer func() {
-		if r := recover(); r != nil {
-			if ye, ok := r.(yamlError); ok {
-				err = ye.error
-			} else {
-				panic(r)
-			}
-		}
-	}()
-	val = decodeValue(&n)
+	defer recoverYamlError(&err)
+	val = newDecoder(opts).decodeDocument(&n)
+	return
+}
+
+// UnmarshalWithPositions decodes the YAML representation of the given bytes into a dgo.Value using the
+// given options and returns it together with the source positions of all values in the decoded document.
+// The given file name is only used when recording the positions.
+func UnmarshalWithPositions(file string, b []byte, opts *DecodeOptions) (val dgo.Value, ps *Positions, err error) {
+	if err = checkInputSize(file, b, opts); err != nil {
+		return
+	}
+	var n y3.Node
+	if err = y3.Unmarshal(b, &n); err != nil {
+		err = syntaxError(file, err)
+		return
+	}
+	defer recoverYamlError(&err)
+	d := newDecoder(opts)
+	d.positions = newPositions(file)
+	val = d.decodeDocument(&n)
+	ps = d.positions
 	return
 }
 
-func decodeScalar(n *y3.Node) dgo.Value {
+// decoder holds the state of an ongoing decoding of a yaml.Node
+type decoder struct {
+	DecodeOptions
+
+	// path is the path from the document root to the node currently being decoded. Each element is
+	// either a dgo.Value map key or an int array index
+	path []interface{}
+
+	// positions is nil unless positions should be recorded
+	positions *Positions
+
+	// comments is nil unless comments should be recorded
+	comments *comments
+
+	// anchors maps anchored nodes to their decoded values so that aliases can share the same instance
+	anchors map[*y3.Node]dgo.Value
+
+	// violations are the values that didn't match their expected type when the Type option is set
+	violations []*Violation
+}
+
+func newDecoder(opts *DecodeOptions) *decoder {
+	d := &decoder{}
+	if opts != nil {
+		d.DecodeOptions = *opts
+	}
+	return d
+}
+
+// intPattern matches the integer forms of the YAML 1.2 core schema
+var intPattern = regexp.MustCompile(`\A(?:[-+]?[0-9]+|0o[0-7]+|0x[0-9a-fA-F]+)\z`)
+
+// floatPattern matches the finite float forms of the YAML 1.2 core schema
+var floatPattern = regexp.MustCompile(`\A[-+]?(?:\.[0-9]+|[0-9]+(?:\.[0-9]*)?)(?:[eE][-+]?[0-9]+)?\z`)
+
+func (d *decoder) decodeScalar(n *y3.Node) dgo.Value {
+	if d.YAML11 && n.Style == 0 {
+		if v := yaml11Scalar(n.Value); v != nil {
+			return v
+		}
+	}
 	var v dgo.Value
-	switch n.Tag {
+	tag := n.Tag
+	if n.Style == 0 && (tag == `!!float` || tag == `!!str`) && intPattern.MatchString(n.Value) {
+		// Plain integers that are too large for an int64 are resolved as floats or strings by the parser. Values
+		// that aren't valid integers, such as the leading zero decimal 089, keep the tag given by the parser.
+		if i, ok := parseInteger(n.Value); ok {
+			return i
+		}
+	}
+	if n.Style == 0 && tag == `!!str` && floatPattern.MatchString(n.Value) {
+		// Plain floats that are too large for a float64 are resolved as strings by the parser
+		tag = `!!float`
+	}
+	switch tag {
 	case `!!null`:
 		v = vf.Nil
 	case `!!bool`:
@@ -43,19 +215,15 @@ func decodeScalar(n *y3.Node) dgo.Value {
 		_ = n.Decode(&x)
 		v = vf.Boolean(x)
 	case `!!int`:
-		var x int64
-		_ = n.Decode(&x)
-		v = vf.Integer(x)
+		v = d.decodeInteger(n)
 	case `!!float`:
-		var x float64
-		_ = n.Decode(&x)
-		v = vf.Float(x)
+		v = d.decodeFloat(n)
 	case `!!str`:
 		v = vf.String(n.Value)
 	case `!!timestamp`:
 		var x time.Time
 		if err := n.Decode(&x); err != nil {
-			panic(yamlError{err})
+			d.fail(n, err)
 		}
 		v = vf.Time(x)
 	case `!!binary`:
@@ -65,43 +233,312 @@ func decodeScalar(n *y3.Node) dgo.Value {
 	default:
 		var x interface{}
 		if err := n.Decode(&x); err != nil {
-			panic(yamlError{err})
+			d.fail(n, err)
 		}
 		v = vf.Value(x)
 	}
 	return v
 }
 
-func decodeValue(n *y3.Node) dgo.Value {
+// decodeInteger decodes an integer into a dgo.Integer, or into a dgo.BigInt when it is too large
+// for an int64.
+func (d *decoder) decodeInteger(n *y3.Node) dgo.Integer {
+	i, ok := parseInteger(n.Value)
+	if !ok {
+		d.fail(n, fmt.Errorf("cannot decode %s `%s` as an integer", n.Tag, n.Value))
+	}
+	return i
+}
+
+// parseInteger parses the given string into a dgo.Integer, or into a dgo.BigInt when it is too large for an
+// int64. Underscores are removed before parsing, in the same way as the YAML parser does.
+func parseInteger(s string) (dgo.Integer, bool) {
+	s = strings.ReplaceAll(s, `_`, ``)
+	if x, err := strconv.ParseInt(s, 0, 64); err == nil {
+		return vf.Integer(x), true
+	}
+	if bi, ok := new(big.Int).SetString(s, 0); ok {
+		return vf.BigInt(bi), true
+	}
+	return nil, false
+}
+
+// decodeFloat decodes a float into a dgo.Float, or into a dgo.BigFloat when the BigFloats option is
+// set or when it is too large for a float64.
+func (d *decoder) decodeFloat(n *y3.Node) dgo.Float {
+	if d.BigFloats {
+		if bf, ok := parseBigFloat(n.Value); ok {
+			return vf.BigFloat(bf)
+		}
+	}
+	var x float64
+	err := n.Decode(&x)
+	if err == nil {
+		return vf.Float(x)
+	}
+	if bf, ok := parseBigFloat(n.Value); ok {
+		return vf.BigFloat(bf)
+	}
+	d.fail(n, err)
+	return nil
+}
+
+// parseBigFloat parses the given string into a big.Float with a precision that is sufficient to retain
+// all of its decimal digits
+func parseBigFloat(s string) (*big.Float, bool) {
+	digits := 0
+	for _, c := range s {
+		if c == 'e' || c == 'E' {
+			break
+		}
+		if c >= '0' && c <= '9' {
+			digits++
+		}
+	}
+	prec := uint(math.Ceil(float64(digits) * math.Log2(10)))
+	if prec < 64 {
+		prec = 64
+	}
+	bf, _, err := big.ParseFloat(s, 10, prec, big.ToNearestEven)
+	return bf, err == nil
+}
+
+// decodeDocument decodes the given document node, using the Type option when it is set
+func (d *decoder) decodeDocument(n *y3.Node) dgo.Value {
+	d.checkLimits(n)
+	if d.StrictJSON {
+		d.checkJSON(n)
+	}
+	if d.Type == nil {
+		return d.decodeValue(n)
+	}
+	v := d.decodeAs(n, d.Type)
+	if len(d.violations) > 0 {
+		panic(yamlError{&ValidationError{Violations: d.violations}})
+	}
+	return v
+}
+
+func (d *decoder) decodeValue(n *y3.Node) dgo.Value {
 	var v dgo.Value
 	switch n.Kind {
 	case y3.DocumentNode:
-		v = decodeValue(n.Content[0])
-	case y3.SequenceNode:
-		v = decodeArray(n)
-	case y3.MappingNode:
-		v = decodeMap(n)
+		return d.decodeValue(n.Content[0])
+	case y3.AliasNode:
+		v = d.decodeAlias(n)
+	default:
+		v = d.decodeNode(n)
+	}
+	d.record(n)
+	return v
+}
+
+// record records the position and comments of the given node when requested
+func (d *decoder) record(n *y3.Node) {
+	if d.positions != nil {
+		d.positions.addValue(d.path, n)
+	}
+	if d.comments != nil {
+		d.comments.addValue(d.path, n)
+	}
+}
+
+// decodeNode decodes a scalar, sequence, or mapping node
+func (d *decoder) decodeNode(n *y3.Node) dgo.Value {
+	if v, ok := d.decodeTagged(n); ok {
+		d.anchor(n, v)
+		return v
+	}
+	if !knownTags[n.Tag] {
+		return d.decodeUnknownTag(n)
+	}
+	var v dgo.Value
+	switch {
+	case n.Tag == `!!set`:
+		v = d.decodeSet(n)
+	case n.Tag == `!!omap`:
+		v = d.decodeOMap(n)
+	case n.Tag == `!!pairs`:
+		v = d.decodePairs(n)
+	case n.Kind == y3.SequenceNode:
+		v = d.decodeArray(n)
+	case n.Kind == y3.MappingNode:
+		v = d.decodeMap(n)
 	default:
-		v = decodeScalar(n)
+		v = d.decodeScalar(n)
+		d.anchor(n, v)
 	}
 	return v
 }
 
-func decodeArray(n *y3.Node) dgo.Array {
+// decodeDetached decodes a node that is not reachable using a path, such as a map key or the value of a
+// merge key. Positions and comments are not recorded for such nodes.
+func (d *decoder) decodeDetached(n *y3.Node) dgo.Value {
+	ps := d.positions
+	cs := d.comments
+	d.positions = nil
+	d.comments = nil
+	k := d.decodeValue(n)
+	d.positions = ps
+	d.comments = cs
+	return k
+}
+
+// anchor associates the given value with the given node if the node has an anchor. Collections are anchored
+// before their contents are decoded so that the contents can contain aliases to the collection itself.
+func (d *decoder) anchor(n *y3.Node, v dgo.Value) {
+	if n.Anchor != `` {
+		if d.anchors == nil {
+			d.anchors = make(map[*y3.Node]dgo.Value)
+		}
+		d.anchors[n] = v
+	}
+}
+
+// decodeAlias returns the value of the anchored node that the given alias refers to. The same value instance
+// is returned for all aliases of an anchor.
+func (d *decoder) decodeAlias(n *y3.Node) dgo.Value {
+	if v, ok := d.anchors[n.Alias]; ok {
+		return v
+	}
+	if n.Alias == nil || n.Alias.Kind == y3.AliasNode {
+		d.fail(n, fmt.Errorf(`alias '%s' does not refer to an anchored value`, n.Value))
+	}
+	return d.decodeValue(n.Alias)
+}
+
+func (d *decoder) decodeArray(n *y3.Node) dgo.Array {
+	return d.decodeArrayWith(n, func(_ int, en *y3.Node) dgo.Value { return d.decodeValue(en) })
+}
+
+// decodeArrayWith decodes a sequence node using the given function to decode each element. The path of
+// the element is current when the function is called.
+func (d *decoder) decodeArrayWith(n *y3.Node, decodeElement func(int, *y3.Node) dgo.Value) dgo.Array {
 	ms := n.Content
-	es := make([]dgo.Value, len(ms))
+	a := vf.WrapSlice(make([]dgo.Value, len(ms)))
+	d.anchor(n, a)
 	for i, me := range ms {
-		es[i] = decodeValue(me)
+		d.path = append(d.path, i)
+		a.Set(i, decodeElement(i, me))
+		d.path = d.path[:len(d.path)-1]
 	}
-	return vf.WrapSlice(es)
+	return a
 }
 
-func decodeMap(n *y3.Node) dgo.Map {
+func (d *decoder) decodeMap(n *y3.Node) dgo.Map {
+	return d.decodeMapWith(n, func(_ dgo.Value, _, vn *y3.Node) dgo.Value { return d.decodeValue(vn) })
+}
+
+// decodeMapWith decodes a mapping node using the given function to decode the value of each entry. The
+// function is called with the decoded key, the key node, and the value node. The path of the value is
+// current when the function is called.
+func (d *decoder) decodeMapWith(n *y3.Node, decodeEntry func(dgo.Value, *y3.Node, *y3.Node) dgo.Value) dgo.Map {
 	ms := n.Content
 	top := len(ms)
 	m := vf.MapWithCapacity(top)
+	d.anchor(n, m)
+	var merges []mergeSource
 	for i := 0; i < top; i += 2 {
-		m.Put(decodeValue(ms[i]), decodeValue(ms[i+1]))
+		kn := ms[i]
+		if kn.Tag == `!!merge` {
+			merges = append(merges, mergeSource{at: m.Len(), node: ms[i+1]})
+			continue
+		}
+		k := d.decodeDetached(kn)
+		d.path = append(d.path, k)
+		if !d.AllowDuplicateKeys && m.ContainsKey(k) {
+			d.duplicateKey(k, kn, mappingKeys(ms))
+		}
+		if d.positions != nil {
+			d.positions.addKey(d.path, kn)
+		}
+		if d.comments != nil {
+			d.comments.addKey(d.path, kn)
+		}
+		m.Put(k, decodeEntry(k, kn, ms[i+1]))
+		d.path = d.path[:len(d.path)-1]
+	}
+	if merges != nil {
+		d.merge(m, merges)
 	}
 	return m
 }
+
+// duplicateKey panics with a *DuplicateKeyError for the given key node. The given nodes are the key nodes of
+// the collection that contains the key, in order. Merge keys among them are ignored.
+func (d *decoder) duplicateKey(k dgo.Value, kn *y3.Node, kns []*y3.Node) {
+	var pn *y3.Node
+	for i := 0; pn == nil; i++ {
+		if kns[i].Tag != `!!merge` && k.Equals(d.decodeDetached(kns[i])) {
+			pn = kns[i]
+		}
+	}
+	panic(yamlError{&DuplicateKeyError{Key: k, Path: pathString(d.path), Position: d.position(kn), Previous: d.position(pn)}})
+}
+
+// mappingKeys returns the key nodes of a mapping node with the given contents
+func mappingKeys(ms []*y3.Node) []*y3.Node {
+	kns := make([]*y3.Node, len(ms)/2)
+	for i := range kns {
+		kns[i] = ms[2*i]
+	}
+	return kns
+}
+
+// position returns the position of the given node
+func (d *decoder) position(n *y3.Node) Position {
+	if d.positions != nil {
+		return d.positions.position(n)
+	}
+	return Position{Line: n.Line, Column: n.Column}
+}
+
+// mergeSource is the value node of a merge key and the number of explicit entries that precede it
+type mergeSource struct {
+	at   int
+	node *y3.Node
+}
+
+// merge adds the entries of the maps designated by the given merge sources to the given map, which
+// contains the explicit entries of a mapping. The result uses YAML 1.1 merge semantics: explicit entries
+// override merged entries, and entries from earlier maps in a sequence override those of later maps. Merged
+// entries are inserted at the position of their merge key.
+func (d *decoder) merge(m dgo.Map, merges []mergeSource) {
+	explicit := m.Copy(false)
+	m.RemoveAll(m.Keys())
+	at := 0
+	add := func(e dgo.MapEntry) {
+		if !(explicit.ContainsKey(e.Key()) || m.ContainsKey(e.Key())) {
+			m.Put(e.Key(), e.Value())
+		}
+	}
+	explicit.EachEntry(func(e dgo.MapEntry) {
+		for len(merges) > 0 && merges[0].at == at {
+			d.mergeSources(merges[0].node).Each(func(mv dgo.Value) { mv.(dgo.Map).EachEntry(add) })
+			merges = merges[1:]
+		}
+		m.Put(e.Key(), e.Value())
+		at++
+	})
+	for _, mg := range merges {
+		d.mergeSources(mg.node).Each(func(mv dgo.Value) { mv.(dgo.Map).EachEntry(add) })
+	}
+}
+
+// mergeSources decodes the value of a merge key, which must be a map or a sequence of maps, and returns
+// the maps in order of precedence.
+func (d *decoder) mergeSources(n *y3.Node) dgo.Array {
+	var ms dgo.Array
+	switch v := d.decodeDetached(n).(type) {
+	case dgo.Map:
+		ms = vf.WrapSlice([]dgo.Value{v})
+	case dgo.Array:
+		if v.All(func(e dgo.Value) bool { _, ok := e.(dgo.Map); return ok }) {
+			ms = v
+		}
+	}
+	if ms == nil {
+		d.fail(n, errors.New(`map merge requires a map or a sequence of maps as the value`))
+	}
+	return ms
+}
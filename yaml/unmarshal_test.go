@@ -1,10 +1,14 @@
 package yaml_test
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"math/big"
 	"testing"
 	"time"
 
+	"github.com/tada/dgo/dgo"
 	"github.com/tada/dgo/test/require"
 	"github.com/tada/dgo/tf"
 	"github.com/tada/dgo/typ"
@@ -88,3 +92,144 @@ func TestUnmarshal_array(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, vf.Values(`hello`, true, 1, 3.14, nil), a)
 }
+
+func TestUnmarshal_bigInt(t *testing.T) {
+	v, err := yaml.Unmarshal([]byte("a: 18446744073709551616\nb: -18446744073709551616\nc: 0x1ffffffffffffffff\n"))
+	require.NoError(t, err)
+	a, _ := new(big.Int).SetString(`18446744073709551616`, 10)
+	b := new(big.Int).Neg(a)
+	c, _ := new(big.Int).SetString(`1ffffffffffffffff`, 16)
+	require.Equal(t, vf.Map(`a`, vf.BigInt(a), `b`, vf.BigInt(b), `c`, vf.BigInt(c)), v)
+
+	bs, err := yaml.Marshal(v)
+	require.NoError(t, err)
+	require.Equal(t, `a: !!int 18446744073709551616
+b: !!int -18446744073709551616
+c: !!int 36893488147419103231
+`, string(bs))
+
+	v2, err := yaml.Unmarshal(bs)
+	require.NoError(t, err)
+	require.Equal(t, v, v2)
+}
+
+func TestUnmarshal_leadingZeroDecimal(t *testing.T) {
+	v, err := yaml.Unmarshal([]byte(`089`))
+	require.NoError(t, err)
+	require.Equal(t, vf.Float(89), v)
+
+	v, err = yaml.Unmarshal([]byte(`a: 09`))
+	require.NoError(t, err)
+	require.Equal(t, vf.Map(`a`, 9.0), v)
+}
+
+func TestUnmarshal_underscoreInt(t *testing.T) {
+	v, err := yaml.Unmarshal([]byte("a: 1__0\nb: 1000_\nc: !!int 0x_1F\n"))
+	require.NoError(t, err)
+	require.Equal(t, vf.Map(`a`, 10, `b`, 1000, `c`, 31), v)
+}
+
+func TestUnmarshal_badInt(t *testing.T) {
+	_, err := yaml.Unmarshal([]byte("a: !!int abc\n"))
+	require.Error(t, `cannot decode !!int `+"`abc`"+` as an integer`, err)
+}
+
+func TestUnmarshal_bigFloat(t *testing.T) {
+	v, err := yaml.Unmarshal([]byte("a: !!float 1e400\nb: 3.14159265358979323846264338327950288\n"))
+	require.NoError(t, err)
+	m := v.(dgo.Map)
+	a, ok := m.Get(`a`).(dgo.BigFloat)
+	require.True(t, ok)
+	require.Equal(t, `1e+400`, a.GoBigFloat().Text('g', -1))
+	require.Equal(t, vf.Float(3.141592653589793), m.Get(`b`))
+
+	bs, err := yaml.Marshal(v)
+	require.NoError(t, err)
+	require.Equal(t, "a: !!float 1e+400\nb: 3.141592653589793\n", string(bs))
+}
+
+func TestUnmarshal_bigFloat_plain(t *testing.T) {
+	for _, opts := range []*yaml.DecodeOptions{nil, {BigFloats: true}} {
+		v, err := yaml.UnmarshalWithOptions([]byte("a: 1.5e400\nb: -.5E+400\n"), opts)
+		require.NoError(t, err)
+		m := v.(dgo.Map)
+		a, ok := m.Get(`a`).(dgo.BigFloat)
+		require.True(t, ok)
+		require.Equal(t, `1.5e+400`, a.GoBigFloat().Text('g', -1))
+		b, ok := m.Get(`b`).(dgo.BigFloat)
+		require.True(t, ok)
+		require.Equal(t, `-5e+399`, b.GoBigFloat().Text('g', -1))
+	}
+}
+
+func TestUnmarshal_badFloat(t *testing.T) {
+	_, err := yaml.Unmarshal([]byte("a: !!float abc\n"))
+	require.Error(t, `cannot decode !!str`, err)
+}
+
+func TestUnmarshalWithOptions_bigFloats(t *testing.T) {
+	const pi = `3.14159265358979323846264338327950288`
+	v, err := yaml.UnmarshalWithOptions([]byte("a: "+pi+"\nb: .inf\nc: -.5\n"), &yaml.DecodeOptions{BigFloats: true})
+	require.NoError(t, err)
+	m := v.(dgo.Map)
+	a, ok := m.Get(`a`).(dgo.BigFloat)
+	require.True(t, ok)
+	require.Equal(t, pi, a.GoBigFloat().Text('g', -1))
+	require.Equal(t, vf.Float(math.Inf(1)), m.Get(`b`))
+	c, ok := m.Get(`c`).(dgo.BigFloat)
+	require.True(t, ok)
+	require.Equal(t, `-0.5`, c.GoBigFloat().Text('g', -1))
+
+	bs, err := yaml.Marshal(vf.Map(`a`, a))
+	require.NoError(t, err)
+	require.Equal(t, "a: "+pi+"\n", string(bs))
+}
+
+func TestUnmarshalWithOptions_bad_yaml(t *testing.T) {
+	_, err := yaml.UnmarshalWithOptions([]byte(": :\n"), &yaml.DecodeOptions{})
+	require.Error(t, `did not find expected key`, err)
+}
+
+func TestUnmarshal_duplicateKey(t *testing.T) {
+	_, err := yaml.Unmarshal([]byte(`
+host: a
+port: 22
+host: b
+`))
+	require.Error(t, `4:1: duplicate key "host", previously defined at 2:1`, err)
+
+	var dk *yaml.DuplicateKeyError
+	require.True(t, errors.As(err, &dk))
+	require.Equal(t, `host`, dk.Key)
+	require.Equal(t, `host`, dk.Path)
+	require.Equal(t, yaml.Position{Line: 4, Column: 1}, dk.Position)
+	require.Equal(t, yaml.Position{Line: 2, Column: 1}, dk.Previous)
+
+	_, err = yaml.Unmarshal([]byte(`
+a:
+  <<: {x: 1}
+  0x10: one
+  y: 2
+  16: two
+`))
+	require.Error(t, `6:3: duplicate key 16, previously defined at 4:3`, err)
+
+	_, err = yaml.Unmarshal([]byte(`
+? {a: [1, 2]}
+: first
+? {a: [1, 2]}
+: second
+`))
+	require.Equal(t, `4:3: duplicate key {"a":{1,2}}, previously defined at 2:3`, err.Error())
+}
+
+func TestUnmarshalWithPositions_duplicateKey(t *testing.T) {
+	_, _, err := yaml.UnmarshalWithPositions(`params.yaml`, []byte("a:\n  b: 1\n  'b': 2\n"), nil)
+	require.Error(t, `params.yaml:3:3: duplicate key "b", previously defined at params.yaml:2:3`, err)
+}
+
+func TestUnmarshalWithOptions_allowDuplicateKeys(t *testing.T) {
+	v, err := yaml.UnmarshalWithOptions([]byte("a: 1\nb: 2\na: 3\n"), &yaml.DecodeOptions{AllowDuplicateKeys: true})
+	require.NoError(t, err)
+	require.Equal(t, vf.Map(`a`, 3, `b`, 2), v)
+}
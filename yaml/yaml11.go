@@ -0,0 +1,33 @@
+package yaml
+
+import (
+	"regexp"
+
+	"github.com/tada/dgo/dgo"
+	"github.com/tada/dgo/vf"
+)
+
+// yaml11NonIntPattern matches plain scalars that the decoder resolves as integers but that YAML 1.1 resolves
+// as strings, i.e. "0o" prefixed octals and leading zero numbers with digits that aren't octal
+var yaml11NonIntPattern = regexp.MustCompile(`\A[-+]?0(?:o[0-7_]+|[0-7_]*[89][0-9_]*)\z`)
+
+// yaml11AmbiguousPattern matches strings that YAML 1.1 parsers resolve as booleans or sexagesimal numbers
+var yaml11AmbiguousPattern = regexp.MustCompile(
+	`\A(?:[yYnN]|[yY]es|YES|[nN]o|NO|[oO]n|ON|[oO]ff|OFF|[-+]?[0-9][0-9_]*(?::[0-5]?[0-9])+(?:\.[0-9_]*)?)\z`)
+
+// yaml11Scalar returns the value of a plain scalar that the YAML 1.1 resolution rules resolve differently
+// from the YAML 1.2 core schema, or nil if the rules agree. The single letters y and n are not resolved as
+// booleans, in line with most YAML 1.1 parsers. Octal integers in the "0755" form need no special treatment
+// since the decoder always resolves them as integers.
+func yaml11Scalar(s string) dgo.Value {
+	switch s {
+	case `yes`, `Yes`, `YES`, `on`, `On`, `ON`:
+		return vf.True
+	case `no`, `No`, `NO`, `off`, `Off`, `OFF`:
+		return vf.False
+	}
+	if yaml11NonIntPattern.MatchString(s) {
+		return vf.String(s)
+	}
+	return nil
+}
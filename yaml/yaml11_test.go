@@ -0,0 +1,110 @@
+package yaml_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tada/dgo/test/require"
+	"github.com/tada/dgo/vf"
+	"github.com/tada/dgoyaml/yaml"
+)
+
+func ExampleDecodeOptions_yaml11() {
+	v, err := yaml.UnmarshalWithOptions([]byte(`
+become: yes
+gather_facts: off
+mode: 0755
+`), &yaml.DecodeOptions{YAML11: true})
+	if err == nil {
+		fmt.Println(v.String())
+	}
+	// Output: {"become":true,"gather_facts":false,"mode":493}
+}
+
+func ExampleEncodeOptions_yaml11() {
+	bs, err := yaml.MarshalWithOptions(vf.Map(`on`, vf.Strings(`push`), `answer`, `no`, `time`, `1:20`),
+		&yaml.EncodeOptions{YAML11: true})
+	if err == nil {
+		fmt.Print(string(bs))
+	}
+	// Output:
+	// "on":
+	//   - push
+	// answer: "no"
+	// time: "1:20"
+}
+
+func TestUnmarshalWithOptions_yaml11(t *testing.T) {
+	opts := &yaml.DecodeOptions{YAML11: true}
+	tests := []struct {
+		source   string
+		expected interface{}
+	}{
+		{`yes`, true},
+		{`Yes`, true},
+		{`YES`, true},
+		{`on`, true},
+		{`On`, true},
+		{`ON`, true},
+		{`no`, false},
+		{`No`, false},
+		{`NO`, false},
+		{`off`, false},
+		{`Off`, false},
+		{`OFF`, false},
+		{`true`, true},
+		{`y`, `y`},
+		{`n`, `n`},
+		{`yEs`, `yEs`},
+		{`'yes'`, `yes`},
+		{`!!str yes`, `yes`},
+		{`0755`, 493},
+		{`-0755`, -493},
+		{`0b101`, 5},
+		{`0x1f`, 31},
+		{`09`, `09`},
+		{`0o17`, `0o17`},
+		{`42`, 42},
+		{`4.2`, 4.2},
+	}
+	for _, tt := range tests {
+		v, err := yaml.UnmarshalWithOptions([]byte(tt.source), opts)
+		require.NoError(t, err)
+		require.Equal(t, tt.expected, v)
+	}
+
+	v, err := yaml.UnmarshalWithOptions([]byte(`[yes, off]`), nil)
+	require.NoError(t, err)
+	require.Equal(t, vf.Strings(`yes`, `off`), v)
+}
+
+func TestMarshalWithOptions_yaml11(t *testing.T) {
+	v := vf.Strings(`yes`, `Off`, `y`, `N`, `1:20`, `-1:30.5`, `0755`, `yep`, `12:60`, "on\nmore")
+	bs, err := yaml.MarshalWithOptions(v, &yaml.EncodeOptions{YAML11: true})
+	require.NoError(t, err)
+	require.Equal(t, `- "yes"
+- "Off"
+- "y"
+- "N"
+- "1:20"
+- "-1:30.5"
+- "0755"
+- yep
+- 12:60
+- |-
+  on
+  more
+`, string(bs))
+
+	bs, err = yaml.MarshalWithOptions(vf.Map(`on`, `y`), &yaml.EncodeOptions{YAML11: true, Quote: yaml.QuoteSingle})
+	require.NoError(t, err)
+	require.Equal(t, "'on': 'y'\n", string(bs))
+
+	bs, err = yaml.Marshal(vf.Map(`on`, `y`))
+	require.NoError(t, err)
+	require.Equal(t, "on: y\n", string(bs))
+
+	rv, err := yaml.UnmarshalWithOptions([]byte(`{"on": "yes", off: no}`), &yaml.DecodeOptions{YAML11: true})
+	require.NoError(t, err)
+	require.Equal(t, vf.Map(`on`, `yes`, false, false), rv)
+}
@@ -18,9 +18,17 @@ func unknownTagNode() *yaml.Node {
 }
 
 func Test_decodeScalar_unknownTagNode(t *testing.T) {
-	require.Equal(t, `a`, decodeScalar(unknownTagNode()))
+	require.Equal(t, `a`, (&decoder{}).decodeScalar(unknownTagNode()))
 }
 
 func Test_decodeScalar_badNode(t *testing.T) {
-	require.Panic(t, func() { decodeScalar(badNode()) }, `value contains itself`)
+	require.Panic(t, func() { (&decoder{}).decodeScalar(badNode()) }, `value contains itself`)
+}
+
+func Test_decodeAlias_unresolved(t *testing.T) {
+	n := &yaml.Node{Kind: yaml.AliasNode, Value: `a`}
+	require.Panic(t, func() { (&decoder{}).decodeValue(n) }, `alias 'a' does not refer to an anchored value`)
+	require.Panic(t, func() { (&decoder{}).decodeValue(badNode()) }, `alias '' does not refer to an anchored value`)
+	n.Alias = unknownTagNode()
+	require.Equal(t, NewTagged(`!something:here`, `a`), (&decoder{}).decodeValue(n))
 }